@@ -0,0 +1,458 @@
+// Package graph builds the dependency graph of Kusion resources. The edges come from the explicit
+// dependsOn, the "$kusion_path" references in the resource attributes and the order of the
+// Kubernetes kinds in each namespace. The graph detects dependency cycles, removes the redundant
+// edges and sorts the resources topologically, for both Kubernetes and Terraform resources.
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// KusionPathPrefix is the prefix of the implicit references to the attributes of other resources,
+// e.g. "$kusion_path.hashicorp:aws:aws_db_instance:mysql.address".
+const KusionPathPrefix = "$kusion_path."
+
+// EdgeKind is the source of a dependency edge.
+type EdgeKind string
+
+const (
+	// EdgeDependsOn is declared by the dependsOn of the resource.
+	EdgeDependsOn EdgeKind = "dependsOn"
+	// EdgeReference is inferred from a "$kusion_path" reference in the resource attributes.
+	EdgeReference EdgeKind = "reference"
+	// EdgeKindOrder is inferred from the order of the Kubernetes kinds.
+	EdgeKindOrder EdgeKind = "kindOrder"
+)
+
+// DefaultOrderedKinds provides the default order of Kubernetes resource kinds.
+var DefaultOrderedKinds = []string{
+	"Namespace",
+	"ResourceQuota",
+	"StorageClass",
+	"CustomResourceDefinition",
+	"ServiceAccount",
+	"PodSecurityPolicy",
+	"Role",
+	"ClusterRole",
+	"RoleBinding",
+	"ClusterRoleBinding",
+	"ConfigMap",
+	"Secret",
+	"Endpoints",
+	"Service",
+	"LimitRange",
+	"PriorityClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"Deployment",
+	"StatefulSet",
+	"CronJob",
+	"PodDisruptionBudget",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// Options are the options to build the graph.
+type Options struct {
+	// OrderedKinds is the order of the Kubernetes kinds, DefaultOrderedKinds is used if it is empty.
+	// Kinds not in the list come after all listed kinds.
+	OrderedKinds []string
+	// DisableKindOrder disables the edges inferred from the order of the Kubernetes kinds.
+	DisableKindOrder bool
+}
+
+// Edge is a dependency edge, From depends on To.
+type Edge struct {
+	From string
+	To   string
+	Kind EdgeKind
+}
+
+// CycleError is returned when the dependencies contain a cycle.
+type CycleError struct {
+	// Path is the IDs of the resources in the cycle, the first and the last ID are the same.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle detected: " + strings.Join(e.Path, " -> ")
+}
+
+// Graph is the dependency graph of resources.
+type Graph struct {
+	nodes []*node
+	index map[string]*node
+}
+
+type node struct {
+	id       string
+	order    int
+	resource *v1.Resource
+	deps     map[string]EdgeKind
+	// external are the dependsOn of the resource which are not in the graph, e.g. the resources
+	// generated by other modules
+	external []string
+}
+
+// New builds the dependency graph of the resources.
+func New(resources v1.Resources, opts *Options) (*Graph, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	g := &Graph{index: make(map[string]*node, len(resources))}
+	for i := range resources {
+		id := resources[i].ID
+		if id == "" {
+			return nil, fmt.Errorf("resource at index %d has an empty id", i)
+		}
+		if _, ok := g.index[id]; ok {
+			return nil, fmt.Errorf("duplicate resource id %s", id)
+		}
+		n := &node{id: id, order: i, resource: &resources[i], deps: map[string]EdgeKind{}}
+		g.nodes = append(g.nodes, n)
+		g.index[id] = n
+	}
+
+	for _, n := range g.nodes {
+		for _, dep := range n.resource.DependsOn {
+			if _, ok := g.index[dep]; ok {
+				n.deps[dep] = EdgeDependsOn
+			} else {
+				n.external = append(n.external, dep)
+			}
+		}
+		WalkStrings(n.resource.Attributes, func(s string) {
+			if dep, ok := g.referencedID(s); ok {
+				g.addEdge(n, dep, EdgeReference)
+			}
+		})
+	}
+	if !opts.DisableKindOrder {
+		orderedKinds := opts.OrderedKinds
+		if len(orderedKinds) == 0 {
+			orderedKinds = DefaultOrderedKinds
+		}
+		g.addKindOrderEdges(orderedKinds)
+	}
+	return g, nil
+}
+
+// AddEdge adds the dependency of the resource from on the resource to.
+func (g *Graph) AddEdge(from, to string, kind EdgeKind) error {
+	n, ok := g.index[from]
+	if !ok {
+		return fmt.Errorf("resource %s not found in graph", from)
+	}
+	if _, ok = g.index[to]; !ok {
+		return fmt.Errorf("resource %s not found in graph", to)
+	}
+	g.addEdge(n, to, kind)
+	return nil
+}
+
+// addEdge adds an edge unless the edge already exists, so a declared dependsOn is never
+// overridden by an inferred one.
+func (g *Graph) addEdge(from *node, to string, kind EdgeKind) {
+	if _, ok := from.deps[to]; !ok {
+		from.deps[to] = kind
+	}
+}
+
+// Dependencies returns the IDs of the resources the resource depends on directly, in the order of the
+// input resources.
+func (g *Graph) Dependencies(id string) []string {
+	n, ok := g.index[id]
+	if !ok {
+		return nil
+	}
+	return g.sortedDeps(n)
+}
+
+// Edges returns all edges of the graph.
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, n := range g.nodes {
+		for _, dep := range g.sortedDeps(n) {
+			edges = append(edges, Edge{From: n.id, To: dep, Kind: n.deps[dep]})
+		}
+	}
+	return edges
+}
+
+// TopologicalSort returns the IDs of the resources with every resource after its dependencies. The
+// order of the input resources is kept as much as possible. A *CycleError is returned if the
+// dependencies contain a cycle.
+func (g *Graph) TopologicalSort() ([]string, error) {
+	// the resources waiting for each resource
+	dependents := make(map[string][]*node, len(g.nodes))
+	pending := make(map[string]int, len(g.nodes))
+	for _, n := range g.nodes {
+		pending[n.id] = len(n.deps)
+		for dep := range n.deps {
+			dependents[dep] = append(dependents[dep], n)
+		}
+	}
+
+	var ready []*node
+	for _, n := range g.nodes {
+		if pending[n.id] == 0 {
+			ready = append(ready, n)
+		}
+	}
+	sorted := make([]string, 0, len(g.nodes))
+	for len(ready) > 0 {
+		// pick the ready resource which comes first in the input
+		sort.Slice(ready, func(i, j int) bool { return ready[i].order < ready[j].order })
+		n := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, n.id)
+		for _, d := range dependents[n.id] {
+			pending[d.id]--
+			if pending[d.id] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(sorted) != len(g.nodes) {
+		return nil, g.findCycle()
+	}
+	return sorted, nil
+}
+
+// Reduce removes the edges implied by other paths, which is the transitive reduction of the graph.
+// A *CycleError is returned if the dependencies contain a cycle.
+func (g *Graph) Reduce() error {
+	sorted, err := g.TopologicalSort()
+	if err != nil {
+		return err
+	}
+	position := make(map[string]int, len(sorted))
+	for i, id := range sorted {
+		position[id] = i
+	}
+
+	// reachable[i] is the set of resources resource sorted[i] depends on transitively
+	words := (len(sorted) + 63) / 64
+	reachable := make([][]uint64, len(sorted))
+	for i, id := range sorted {
+		reachable[i] = make([]uint64, words)
+		for dep := range g.index[id].deps {
+			j := position[dep]
+			reachable[i][j/64] |= 1 << (j % 64)
+			for w := range reachable[j] {
+				reachable[i][w] |= reachable[j][w]
+			}
+		}
+	}
+
+	for _, n := range g.nodes {
+		var redundant []string
+		for dep := range n.deps {
+			j := position[dep]
+			for other := range n.deps {
+				if other != dep && reachable[position[other]][j/64]&(1<<(j%64)) != 0 {
+					redundant = append(redundant, dep)
+					break
+				}
+			}
+		}
+		for _, dep := range redundant {
+			delete(n.deps, dep)
+		}
+	}
+	return nil
+}
+
+// Resources returns the resources in topological order, the dependsOn of which are replaced with
+// the dependencies in the graph followed by the dependencies outside the graph. The input resources
+// are not modified. A *CycleError is returned if the dependencies contain a cycle.
+func (g *Graph) Resources() (v1.Resources, error) {
+	sorted, err := g.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+	resources := make(v1.Resources, 0, len(sorted))
+	for _, id := range sorted {
+		n := g.index[id]
+		r := *n.resource
+		r.DependsOn = nil
+		if deps := append(g.sortedDeps(n), n.external...); len(deps) > 0 {
+			r.DependsOn = deps
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// findCycle returns the error of a cycle in the graph.
+func (g *Graph) findCycle() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g.nodes))
+	var stack []string
+	var cycle []string
+
+	var visit func(n *node) bool
+	visit = func(n *node) bool {
+		state[n.id] = visiting
+		stack = append(stack, n.id)
+		for _, dep := range g.sortedDeps(n) {
+			switch state[dep] {
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						cycle = append(append([]string{}, stack[i:]...), dep)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(g.index[dep]) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n.id] = visited
+		return false
+	}
+
+	for _, n := range g.nodes {
+		if state[n.id] == unvisited && visit(n) {
+			return &CycleError{Path: cycle}
+		}
+	}
+	return errors.New("dependency cycle detected")
+}
+
+// sortedDeps returns the dependencies of the node in the order of the input resources.
+func (g *Graph) sortedDeps(n *node) []string {
+	deps := make([]string, 0, len(n.deps))
+	for dep := range n.deps {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return g.index[deps[i]].order < g.index[deps[j]].order })
+	return deps
+}
+
+// referencedID returns the ID of the resource in the graph referenced by the "$kusion_path" reference.
+func (g *Graph) referencedID(s string) (string, bool) {
+	return ResolveReference(s, func(id string) bool {
+		_, ok := g.index[id]
+		return ok
+	})
+}
+
+// ResolveReference returns the ID of the resource referenced by the "$kusion_path" reference, for
+// which exists returns true. Both IDs and attribute paths may contain dots, so the longest existing
+// ID is matched.
+func ResolveReference(ref string, exists func(id string) bool) (string, bool) {
+	if !strings.HasPrefix(ref, KusionPathPrefix) {
+		return "", false
+	}
+	ref = strings.TrimPrefix(ref, KusionPathPrefix)
+	for i := len(ref) - 1; i > 0; i-- {
+		if ref[i] == '.' && exists(ref[:i]) {
+			return ref[:i], true
+		}
+	}
+	return "", false
+}
+
+// addKindOrderEdges makes each Kubernetes resource depend on the resources of the closest earlier kind
+// in the same namespace and in the cluster scope, the earlier kinds are depended on transitively.
+// A Namespace belongs to the scope of the resources in it.
+func (g *Graph) addKindOrderEdges(orderedKinds []string) {
+	rank := make(map[string]int, len(orderedKinds))
+	for i, kind := range orderedKinds {
+		if _, ok := rank[kind]; !ok {
+			rank[kind] = i
+		}
+	}
+
+	// scope -> rank -> resources
+	scopes := map[string]map[int][]*node{}
+	type kubeNode struct {
+		n         *node
+		namespace string
+		rank      int
+	}
+	var kubeNodes []kubeNode
+	for _, n := range g.nodes {
+		if n.resource.Type != v1.Kubernetes {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: n.resource.Attributes}
+		r, ok := rank[u.GetKind()]
+		if !ok {
+			r = len(orderedKinds)
+		}
+		scope := u.GetNamespace()
+		if u.GetKind() == "Namespace" {
+			scope = u.GetName()
+		}
+		if scopes[scope] == nil {
+			scopes[scope] = map[int][]*node{}
+		}
+		scopes[scope][r] = append(scopes[scope][r], n)
+		kubeNodes = append(kubeNodes, kubeNode{n: n, namespace: u.GetNamespace(), rank: r})
+	}
+
+	closestEarlier := func(scope string, r int) []*node {
+		for i := r - 1; i >= 0; i-- {
+			if nodes := scopes[scope][i]; len(nodes) > 0 {
+				return nodes
+			}
+		}
+		return nil
+	}
+	for _, kn := range kubeNodes {
+		deps := closestEarlier(kn.namespace, kn.rank)
+		if kn.namespace != "" {
+			// a namespaced resource depends on the cluster scoped resources too, but not on the
+			// Namespaces of other namespaces
+			deps = append(append([]*node{}, deps...), closestEarlier("", kn.rank)...)
+		}
+		for _, dep := range deps {
+			if dep != kn.n {
+				g.addEdge(kn.n, dep.id, EdgeKindOrder)
+			}
+		}
+	}
+}
+
+// WalkStrings calls fn with every string nested in the value, e.g. the attributes of a resource.
+func WalkStrings(v interface{}, fn func(string)) {
+	switch val := v.(type) {
+	case string:
+		fn(val)
+	case map[string]interface{}:
+		for _, item := range val {
+			WalkStrings(item, fn)
+		}
+	case map[interface{}]interface{}:
+		for _, item := range val {
+			WalkStrings(item, fn)
+		}
+	case []interface{}:
+		for _, item := range val {
+			WalkStrings(item, fn)
+		}
+	case []map[string]interface{}:
+		for _, item := range val {
+			WalkStrings(item, fn)
+		}
+	case []string:
+		for _, item := range val {
+			fn(item)
+		}
+	}
+}
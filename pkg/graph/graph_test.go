@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func kubernetesResource(id, kind, namespace, name string) v1.Resource {
+	metadata := map[string]interface{}{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return v1.Resource{
+		ID:   id,
+		Type: v1.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   metadata,
+		},
+	}
+}
+
+func TestKindOrderPerNamespace(t *testing.T) {
+	resources := v1.Resources{
+		kubernetesResource("apps/v1:Deployment:foo:app", "Deployment", "foo", "app"),
+		kubernetesResource("v1:Service:foo:app", "Service", "foo", "app"),
+		kubernetesResource("v1:ConfigMap:foo:app", "ConfigMap", "foo", "app"),
+		kubernetesResource("v1:Namespace:foo", "Namespace", "", "foo"),
+		kubernetesResource("v1:Namespace:bar", "Namespace", "", "bar"),
+		kubernetesResource("v1:Service:bar:app", "Service", "bar", "app"),
+	}
+	g, err := New(resources, nil)
+	require.NoError(t, err)
+	require.NoError(t, g.Reduce())
+
+	assert.Equal(t, []string{"v1:Service:foo:app"}, g.Dependencies("apps/v1:Deployment:foo:app"))
+	assert.Equal(t, []string{"v1:ConfigMap:foo:app"}, g.Dependencies("v1:Service:foo:app"))
+	assert.Equal(t, []string{"v1:Namespace:foo"}, g.Dependencies("v1:ConfigMap:foo:app"))
+	assert.Equal(t, []string{"v1:Namespace:bar"}, g.Dependencies("v1:Service:bar:app"))
+	assert.Empty(t, g.Dependencies("v1:Namespace:foo"))
+
+	sorted, err := g.TopologicalSort()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"v1:Namespace:foo",
+		"v1:ConfigMap:foo:app",
+		"v1:Service:foo:app",
+		"apps/v1:Deployment:foo:app",
+		"v1:Namespace:bar",
+		"v1:Service:bar:app",
+	}, sorted)
+}
+
+func TestReferencesAndDependsOn(t *testing.T) {
+	resources := v1.Resources{
+		{
+			ID:   "apps/v1:Deployment:foo:app",
+			Type: v1.Kubernetes,
+			Attributes: map[string]interface{}{
+				"kind": "Deployment",
+				"spec": map[string]interface{}{
+					"env": []interface{}{
+						map[string]interface{}{"value": "$kusion_path.hashicorp:aws:aws_db_instance:my.db.address"},
+					},
+				},
+			},
+			DependsOn: []string{"external:resource"},
+		},
+		{
+			ID:   "hashicorp:aws:aws_db_instance:my.db",
+			Type: v1.Terraform,
+			Attributes: map[string]interface{}{
+				"vpc_security_group_ids": []interface{}{"$kusion_path.hashicorp:aws:aws_security_group:sg.id"},
+			},
+		},
+		{
+			ID:        "hashicorp:aws:aws_security_group:sg",
+			Type:      v1.Terraform,
+			DependsOn: []string{"hashicorp:random:random_password:pwd"},
+		},
+		{
+			ID:   "hashicorp:random:random_password:pwd",
+			Type: v1.Terraform,
+		},
+	}
+	g, err := New(resources, &Options{DisableKindOrder: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Edge{
+		{From: "apps/v1:Deployment:foo:app", To: "hashicorp:aws:aws_db_instance:my.db", Kind: EdgeReference},
+		{From: "hashicorp:aws:aws_db_instance:my.db", To: "hashicorp:aws:aws_security_group:sg", Kind: EdgeReference},
+		{From: "hashicorp:aws:aws_security_group:sg", To: "hashicorp:random:random_password:pwd", Kind: EdgeDependsOn},
+	}, g.Edges())
+
+	sorted, err := g.Resources()
+	require.NoError(t, err)
+	var ids []string
+	for _, r := range sorted {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []string{
+		"hashicorp:random:random_password:pwd",
+		"hashicorp:aws:aws_security_group:sg",
+		"hashicorp:aws:aws_db_instance:my.db",
+		"apps/v1:Deployment:foo:app",
+	}, ids)
+	assert.Equal(t, []string{"hashicorp:aws:aws_db_instance:my.db", "external:resource"}, sorted[3].DependsOn)
+	// the input resources are not modified
+	assert.Equal(t, []string{"external:resource"}, resources[0].DependsOn)
+}
+
+func TestReduce(t *testing.T) {
+	resources := v1.Resources{
+		{ID: "a", DependsOn: []string{"b", "c"}},
+		{ID: "b", DependsOn: []string{"c"}},
+		{ID: "c"},
+	}
+	g, err := New(resources, nil)
+	require.NoError(t, err)
+	require.NoError(t, g.Reduce())
+	assert.Equal(t, []string{"b"}, g.Dependencies("a"))
+	assert.Equal(t, []string{"c"}, g.Dependencies("b"))
+}
+
+func TestCycle(t *testing.T) {
+	resources := v1.Resources{
+		{ID: "a", DependsOn: []string{"b"}},
+		{ID: "b", Attributes: map[string]interface{}{"ref": "$kusion_path.c.id"}},
+		{ID: "c", DependsOn: []string{"a"}},
+		{ID: "d"},
+	}
+	g, err := New(resources, nil)
+	require.NoError(t, err)
+
+	_, err = g.TopologicalSort()
+	var cycleErr *CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{"a", "b", "c", "a"}, cycleErr.Path)
+	assert.EqualError(t, err, "dependency cycle detected: a -> b -> c -> a")
+	assert.ErrorAs(t, g.Reduce(), &cycleErr)
+}
+
+func TestNewWithInvalidResources(t *testing.T) {
+	_, err := New(v1.Resources{{ID: "a"}, {ID: "a"}}, nil)
+	assert.Error(t, err)
+	_, err = New(v1.Resources{{}}, nil)
+	assert.Error(t, err)
+
+	g, err := New(v1.Resources{{ID: "a"}}, nil)
+	require.NoError(t, err)
+	assert.Error(t, g.AddEdge("a", "b", EdgeDependsOn))
+}
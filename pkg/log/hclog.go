@@ -34,11 +34,12 @@ func GetModuleLogger(ctx context.Context) hclog.Logger {
 		MaxAge:    28,
 	}
 
-	return hclog.New(&hclog.LoggerOptions{
+	// the sensitive key-value pairs logged by modules are redacted
+	return newRedactingLogger(hclog.New(&hclog.LoggerOptions{
 		Name:   moduleName,
 		Output: lumberjackLogger,
 		Level:  hclog.Debug,
-	}).With("trace_id", traceID)
+	})).With("trace_id", traceID)
 }
 
 func getTraceID(ctx context.Context) string {
@@ -0,0 +1,233 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Redacted is the placeholder of the redacted values in logs.
+const Redacted = "******"
+
+// DefaultSensitiveKeyPatterns are the default patterns of the keys whose values are redacted.
+var DefaultSensitiveKeyPatterns = []string{
+	`(?i)passw(or)?d`,
+	`(?i)secret`,
+	`(?i)token`,
+	`(?i)credential`,
+	`(?i)access_?key`,
+	`(?i)private_?key`,
+	`(?i)api_?key`,
+}
+
+var (
+	redactMu         sync.RWMutex
+	sensitivePattern = regexp.MustCompile(joinPatterns(DefaultSensitiveKeyPatterns))
+)
+
+// Sensitive marks a value which must never be written into logs, it is formatted as Redacted.
+// Use Value to get the real value.
+type Sensitive string
+
+// Value returns the real value.
+func (s Sensitive) Value() string {
+	return string(s)
+}
+
+func (s Sensitive) String() string {
+	return Redacted
+}
+
+func (s Sensitive) GoString() string {
+	return Redacted
+}
+
+func (s Sensitive) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// SetSensitiveKeyPatterns replaces the patterns of the keys whose values are redacted. The patterns
+// are regular expressions, which are matched against the keys of maps and logger key-value pairs.
+func SetSensitiveKeyPatterns(patterns ...string) error {
+	re, err := regexp.Compile(joinPatterns(patterns))
+	if err != nil {
+		return fmt.Errorf("invalid sensitive key pattern: %w", err)
+	}
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	sensitivePattern = re
+	return nil
+}
+
+// IsSensitiveKey returns whether the values of the key should be redacted.
+func IsSensitiveKey(key string) bool {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	return sensitivePattern.MatchString(key)
+}
+
+// Redact returns a copy of v in which the values of sensitive keys in any nested map or struct are
+// replaced with Redacted. The pointers are dereferenced, and the structs are converted to maps by
+// their JSON encoding, except the ones whose type names are sensitive, e.g. v1.SecretStore, which
+// are redacted entirely. Errors and scalars are returned unchanged, and the values which can't be
+// walked, e.g. channels and functions, are replaced with Redacted.
+func Redact(v any) any {
+	return redactValue(reflect.ValueOf(v))
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func redactValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Interface && v.Type().Implements(errorType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		if IsSensitiveKey(v.Type().Name()) {
+			return Redacted
+		}
+		out, err := json.Marshal(v.Interface())
+		if err != nil {
+			return Redacted
+		}
+		var decoded any
+		if err = json.Unmarshal(out, &decoded); err != nil {
+			return Redacted
+		}
+		return redactValue(reflect.ValueOf(decoded))
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		// yaml.v2 decodes maps with interface keys, which are matched by their string form
+		stringKeys := v.Type().Key().Kind() == reflect.String
+		out := make(map[string]any, v.Len())
+		anyOut := make(map[any]any)
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			value := any(Redacted)
+			if !IsSensitiveKey(key) {
+				value = redactValue(iter.Value())
+			}
+			if stringKeys {
+				out[key] = value
+			} else {
+				anyOut[iter.Key().Interface()] = value
+			}
+		}
+		if stringKeys {
+			return out
+		}
+		return anyOut
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return Redacted
+	default:
+		return v.Interface()
+	}
+}
+
+// redactFormatArgs redacts the arguments of the unstructured loggers, e.g. Infof and Info.
+func redactFormatArgs(args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		out[i] = Redact(arg)
+	}
+	return out
+}
+
+// RedactArgs redacts the key-value pairs passed to the structured loggers.
+func RedactArgs(args ...interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i := 0; i < len(args); i++ {
+		if i%2 == 1 {
+			if key, ok := args[i-1].(string); ok && IsSensitiveKey(key) {
+				out[i] = Redacted
+				continue
+			}
+		}
+		out[i] = Redact(args[i])
+	}
+	return out
+}
+
+func joinPatterns(patterns []string) string {
+	if len(patterns) == 0 {
+		// matches nothing
+		return `a^`
+	}
+	joined := ""
+	for i, p := range patterns {
+		if i > 0 {
+			joined += "|"
+		}
+		joined += "(?:" + p + ")"
+	}
+	return joined
+}
+
+// redactingLogger is an hclog.Logger redacting the sensitive key-value pairs.
+type redactingLogger struct {
+	hclog.Logger
+}
+
+func newRedactingLogger(l hclog.Logger) hclog.Logger {
+	return &redactingLogger{Logger: l}
+}
+
+func (l *redactingLogger) Log(level hclog.Level, msg string, args ...interface{}) {
+	l.Logger.Log(level, msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) Trace(msg string, args ...interface{}) {
+	l.Logger.Trace(msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) Debug(msg string, args ...interface{}) {
+	l.Logger.Debug(msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) Info(msg string, args ...interface{}) {
+	l.Logger.Info(msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) Warn(msg string, args ...interface{}) {
+	l.Logger.Warn(msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) Error(msg string, args ...interface{}) {
+	l.Logger.Error(msg, RedactArgs(args...)...)
+}
+
+func (l *redactingLogger) With(args ...interface{}) hclog.Logger {
+	return newRedactingLogger(l.Logger.With(RedactArgs(args...)...))
+}
+
+func (l *redactingLogger) Named(name string) hclog.Logger {
+	return newRedactingLogger(l.Logger.Named(name))
+}
+
+func (l *redactingLogger) ResetNamed(name string) hclog.Logger {
+	return newRedactingLogger(l.Logger.ResetNamed(name))
+}
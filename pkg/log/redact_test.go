@@ -0,0 +1,136 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestSensitive(t *testing.T) {
+	s := Sensitive("p@ss")
+	assert.Equal(t, "p@ss", s.Value())
+	assert.Equal(t, Redacted, fmt.Sprintf("%v", s))
+	assert.Equal(t, Redacted, fmt.Sprintf("%#v", s))
+
+	out, err := json.Marshal(map[string]any{"key": s})
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"******"}`, string(out))
+}
+
+func TestRedact(t *testing.T) {
+	in := map[string]any{
+		"name":     "db",
+		"password": "p@ss",
+		"nested": map[interface{}]interface{}{
+			"AccessKey": "ak",
+			"region":    "us-east-1",
+		},
+		"list": []any{map[string]any{"apiToken": "t", "port": 80}},
+	}
+	expected := map[string]any{
+		"name":     "db",
+		"password": Redacted,
+		"nested": map[any]any{
+			"AccessKey": Redacted,
+			"region":    "us-east-1",
+		},
+		"list": []any{map[string]any{"apiToken": Redacted, "port": 80}},
+	}
+	assert.Equal(t, expected, Redact(in))
+	// the input is not modified
+	assert.Equal(t, "p@ss", in["password"])
+	assert.Nil(t, Redact(nil))
+	assert.Equal(t, "foo", Redact("foo"))
+}
+
+type dbConfig struct {
+	Host     string `json:"host"`
+	Password string
+	Auth     *authConfig `json:"auth"`
+	internal string
+}
+
+type authConfig struct {
+	User  string `json:"user"`
+	Token string `json:"token"`
+}
+
+func TestRedactStructs(t *testing.T) {
+	cfg := &dbConfig{Host: "db", Password: "p@ss", Auth: &authConfig{User: "admin", Token: "t"}, internal: "i"}
+	assert.Equal(t, map[string]any{
+		"host":     "db",
+		"Password": Redacted,
+		"auth":     map[string]any{"user": "admin", "token": Redacted},
+	}, Redact(cfg))
+	assert.Equal(t, Redact(cfg), Redact(*cfg))
+	assert.Equal(t, map[string]any{"db": map[string]any{"host": "db", "Password": Redacted, "auth": nil}},
+		Redact(map[string]any{"db": &dbConfig{Host: "db", Password: "p@ss"}}))
+
+	// the types named like sensitive keys are redacted entirely
+	store := &v1.SecretStore{Provider: &v1.ProviderSpec{Vault: &v1.VaultProvider{Server: "https://vault.example.com"}}}
+	assert.Equal(t, Redacted, Redact(store))
+	assert.Equal(t, []any{Redacted}, Redact([]*v1.SecretStore{store}))
+
+	// the values which can't be walked are redacted
+	assert.Equal(t, Redacted, Redact(make(chan int)))
+	assert.Equal(t, Redacted, Redact(func() {}))
+	assert.Equal(t, map[string]any{"f": Redacted}, Redact(map[string]any{"f": func() {}}))
+
+	err := errors.New("failed")
+	assert.Equal(t, err, Redact(err))
+	assert.Nil(t, Redact((*dbConfig)(nil)))
+}
+
+func TestRedactFormatArgs(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := &zapLogger{sugaredLogger: zap.New(core).Sugar()}
+
+	cfg := &dbConfig{Host: "db", Password: "p@ss", Auth: &authConfig{Token: "t"}}
+	logger.Infof("connect to %v", cfg)
+	logger.Info("connect to ", cfg)
+	logger.Errorf("connect failed: %v, %v", errors.New("refused"), map[string]any{"password": "p@ss"})
+	require.Len(t, logs.All(), 3)
+	for _, entry := range logs.All() {
+		assert.NotContains(t, entry.Message, "p@ss")
+		assert.NotContains(t, entry.Message, "token:t")
+	}
+	assert.Contains(t, logs.All()[0].Message, "Password:******")
+	assert.Equal(t, "connect failed: refused, map[password:******]", logs.All()[2].Message)
+}
+
+func TestSetSensitiveKeyPatterns(t *testing.T) {
+	defer func() {
+		require.NoError(t, SetSensitiveKeyPatterns(DefaultSensitiveKeyPatterns...))
+	}()
+
+	require.NoError(t, SetSensitiveKeyPatterns(`^dsn$`))
+	assert.True(t, IsSensitiveKey("dsn"))
+	assert.False(t, IsSensitiveKey("password"))
+
+	require.NoError(t, SetSensitiveKeyPatterns())
+	assert.False(t, IsSensitiveKey("dsn"))
+
+	assert.Error(t, SetSensitiveKeyPatterns(`(`))
+}
+
+func TestRedactingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactingLogger(hclog.New(&hclog.LoggerOptions{Output: &buf, Level: hclog.Trace}))
+
+	logger.With("secret_key", "sk").Info("connect", "password", "p@ss", "config", map[string]any{"token": "t"})
+	out := buf.String()
+	assert.NotContains(t, out, "sk")
+	assert.NotContains(t, out, "p@ss")
+	assert.NotContains(t, out, "token=t")
+	assert.Contains(t, out, `password="******"`)
+}
@@ -72,51 +72,51 @@ func newZapLogger() (Logger, error) {
 }
 
 func (l *zapLogger) Debug(args ...interface{}) {
-	l.sugaredLogger.Debug(args...)
+	l.sugaredLogger.Debug(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Debugf(format string, args ...interface{}) {
-	l.sugaredLogger.Debugf(format, args...)
+	l.sugaredLogger.Debugf(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Infof(format string, args ...interface{}) {
-	l.sugaredLogger.Infof(format, args...)
+	l.sugaredLogger.Infof(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Info(args ...interface{}) {
-	l.sugaredLogger.Info(args...)
+	l.sugaredLogger.Info(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Warnf(format string, args ...interface{}) {
-	l.sugaredLogger.Warnf(format, args...)
+	l.sugaredLogger.Warnf(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Warn(args ...interface{}) {
-	l.sugaredLogger.Warn(args...)
+	l.sugaredLogger.Warn(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Errorf(format string, args ...interface{}) {
-	l.sugaredLogger.Errorf(format, args...)
+	l.sugaredLogger.Errorf(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Error(args ...interface{}) {
-	l.sugaredLogger.Error(args...)
+	l.sugaredLogger.Error(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Panicf(format string, args ...interface{}) {
-	l.sugaredLogger.Panicf(format, args...)
+	l.sugaredLogger.Panicf(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Panic(args ...interface{}) {
-	l.sugaredLogger.Panic(args...)
+	l.sugaredLogger.Panic(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Fatalf(format string, args ...interface{}) {
-	l.sugaredLogger.Fatalf(format, args...)
+	l.sugaredLogger.Fatalf(format, redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) Fatal(args ...interface{}) {
-	l.sugaredLogger.Fatal(args...)
+	l.sugaredLogger.Fatal(redactFormatArgs(args)...)
 }
 
 func (l *zapLogger) SetLevel(level Level) {
@@ -136,7 +136,7 @@ func (l *zapLogger) GetLogDir() Dir {
 }
 
 func (l *zapLogger) With(args ...interface{}) Logger {
-	curLogger := l.sugaredLogger.With(args...)
+	curLogger := l.sugaredLogger.With(RedactArgs(args...)...)
 	return &zapLogger{sugaredLogger: curLogger, defaultLevel: l.defaultLevel, debugLevel: l.debugLevel, errorLevel: l.errorLevel}
 }
 
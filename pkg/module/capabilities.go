@@ -0,0 +1,110 @@
+package module
+
+import (
+	"runtime/debug"
+	"slices"
+
+	"github.com/hashicorp/go-plugin"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+const (
+	// ProtocolVersionLegacy is the protocol version of module binaries which only serve Generate.
+	ProtocolVersionLegacy = 1
+	// ProtocolVersion is the latest protocol version, which adds GetCapabilities and the optional features.
+	ProtocolVersion = 2
+)
+
+// Optional features a module can support, the host should check them before using the feature.
+const (
+	// FeatureValidate means the module validates its inputs in Validate.
+	FeatureValidate = "validate"
+	// FeatureSchema means the module publishes the schemas of its configs in GetSchema.
+	FeatureSchema = "schema"
+	// FeatureDiagnostics means the module reports diagnostics in the GeneratorResponse.
+	FeatureDiagnostics = "diagnostics"
+	// FeaturePanicReport means a module panic is reported to the host as a PanicError.
+	FeaturePanicReport = "panic-report"
+)
+
+// frameworkModulePath is the go module path of this framework.
+const frameworkModulePath = "kusionstack.io/kusion-module-framework"
+
+// Capabilities describes the framework version, protocol versions and features supported by a module.
+type Capabilities struct {
+	// FrameworkVersion is the version of the kusion-module-framework the module is built with
+	FrameworkVersion string `json:"frameworkVersion,omitempty" yaml:"frameworkVersion,omitempty"`
+	// MinProtocolVersion is the lowest protocol version the module supports
+	MinProtocolVersion int `json:"minProtocolVersion" yaml:"minProtocolVersion"`
+	// MaxProtocolVersion is the highest protocol version the module supports
+	MaxProtocolVersion int `json:"maxProtocolVersion" yaml:"maxProtocolVersion"`
+	// Features contains the optional features supported by the module
+	Features []string `json:"features,omitempty" yaml:"features,omitempty"`
+}
+
+// LegacyCapabilities returns the capabilities of module binaries built before the protocol
+// negotiation was introduced, which only serve Generate.
+func LegacyCapabilities() Capabilities {
+	return Capabilities{
+		MinProtocolVersion: ProtocolVersionLegacy,
+		MaxProtocolVersion: ProtocolVersionLegacy,
+	}
+}
+
+// Supports returns whether the feature is supported.
+func (c Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// ToProto converts the capabilities into the proto format.
+func (c Capabilities) ToProto() *proto.CapabilitiesResponse {
+	return &proto.CapabilitiesResponse{
+		FrameworkVersion:   c.FrameworkVersion,
+		MinProtocolVersion: int32(c.MinProtocolVersion),
+		MaxProtocolVersion: int32(c.MaxProtocolVersion),
+		Features:           c.Features,
+	}
+}
+
+// CapabilitiesFromProto converts the proto response back into Capabilities.
+func CapabilitiesFromProto(res *proto.CapabilitiesResponse) Capabilities {
+	return Capabilities{
+		FrameworkVersion:   res.GetFrameworkVersion(),
+		MinProtocolVersion: int(res.GetMinProtocolVersion()),
+		MaxProtocolVersion: int(res.GetMaxProtocolVersion()),
+		Features:           res.GetFeatures(),
+	}
+}
+
+// FrameworkVersion returns the version of this framework recorded in the build info of the running binary.
+func FrameworkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == frameworkModulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == frameworkModulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// VersionedPluginSets returns the plugin sets of all protocol versions served by this framework.
+// The host passes a nil impl, while the module passes its real implementation.
+func VersionedPluginSets(impl Module) map[int]plugin.PluginSet {
+	sets := make(map[int]plugin.PluginSet, ProtocolVersion)
+	for v := ProtocolVersionLegacy; v <= ProtocolVersion; v++ {
+		sets[v] = plugin.PluginSet{
+			PluginKey: &GRPCPlugin{Impl: impl},
+		}
+	}
+	return sets
+}
@@ -0,0 +1,32 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	c := Capabilities{
+		FrameworkVersion:   "v0.2.0",
+		MinProtocolVersion: ProtocolVersionLegacy,
+		MaxProtocolVersion: ProtocolVersion,
+		Features:           []string{FeatureValidate, FeatureDiagnostics},
+	}
+	assert.True(t, c.Supports(FeatureValidate))
+	assert.False(t, c.Supports(FeatureSchema))
+	assert.Equal(t, c, CapabilitiesFromProto(c.ToProto()))
+
+	legacy := LegacyCapabilities()
+	assert.False(t, legacy.Supports(FeatureValidate))
+	assert.Equal(t, ProtocolVersionLegacy, legacy.MaxProtocolVersion)
+}
+
+func TestVersionedPluginSets(t *testing.T) {
+	impl := &FrameworkModuleWrapper{Module: &mockFrameworkModule{}}
+	sets := VersionedPluginSets(impl)
+	assert.Len(t, sets, ProtocolVersion)
+	for v := ProtocolVersionLegacy; v <= ProtocolVersion; v++ {
+		assert.Equal(t, impl, sets[v][PluginKey].(*GRPCPlugin).Impl)
+	}
+}
@@ -0,0 +1,65 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/graph"
+)
+
+// ErrUnresolvedReference is returned when a "$kusion_path" reference points to a resource which is
+// neither generated by the module nor declared in the ExternalDependencies of the response.
+var ErrUnresolvedReference = errors.New("unresolved $kusion_path reference")
+
+// inferDependencies adds the resources referenced by the "$kusion_path" references in the attributes
+// into the dependsOn of each resource.
+func inferDependencies(resources []v1.Resource, external []string) error {
+	known := make(map[string]bool, len(resources)+len(external))
+	for _, r := range resources {
+		known[r.ID] = true
+	}
+	for _, id := range external {
+		known[id] = true
+	}
+	exists := func(id string) bool { return known[id] }
+
+	var errs []error
+	for i := range resources {
+		r := &resources[i]
+		referenced := map[string]bool{}
+		unresolved := map[string]bool{}
+		graph.WalkStrings(r.Attributes, func(s string) {
+			if !strings.HasPrefix(s, graph.KusionPathPrefix) {
+				return
+			}
+			if id, ok := graph.ResolveReference(s, exists); ok {
+				referenced[id] = true
+			} else {
+				unresolved[s] = true
+			}
+		})
+
+		for _, ref := range sortedKeys(unresolved) {
+			errs = append(errs, fmt.Errorf("%w: resource %s references %s", ErrUnresolvedReference, r.ID, ref))
+		}
+		delete(referenced, r.ID)
+		for _, id := range r.DependsOn {
+			delete(referenced, id)
+		}
+		r.DependsOn = append(r.DependsOn, sortedKeys(referenced)...)
+	}
+	return errors.Join(errs...)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,48 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestInferDependencies(t *testing.T) {
+	resources := []v1.Resource{
+		{
+			ID: "apps/v1:Deployment:foo:bar",
+			Attributes: map[string]interface{}{
+				"env": []interface{}{
+					map[string]interface{}{"value": KusionPathDependency("hashicorp:aws:aws_db_instance:db", "address")},
+					map[string]interface{}{"value": KusionPathDependency("v1:Secret:foo:bar", "data.password")},
+					map[string]interface{}{"value": KusionPathDependency("hashicorp:aws:aws_vpc:vpc", "id")},
+				},
+			},
+			DependsOn: []string{"v1:Secret:foo:bar"},
+		},
+		{ID: "hashicorp:aws:aws_db_instance:db"},
+		{ID: "v1:Secret:foo:bar"},
+	}
+
+	require.NoError(t, inferDependencies(resources, []string{"hashicorp:aws:aws_vpc:vpc"}))
+	assert.Equal(t, []string{
+		"v1:Secret:foo:bar",
+		"hashicorp:aws:aws_db_instance:db",
+		"hashicorp:aws:aws_vpc:vpc",
+	}, resources[0].DependsOn)
+	assert.Empty(t, resources[1].DependsOn)
+}
+
+func TestInferDependenciesWithUnresolvedReference(t *testing.T) {
+	resources := []v1.Resource{
+		{
+			ID:         "apps/v1:Deployment:foo:bar",
+			Attributes: map[string]interface{}{"value": KusionPathDependency("hashicorp:aws:aws_vpc:vpc", "id")},
+		},
+	}
+
+	err := inferDependencies(resources, nil)
+	assert.ErrorIs(t, err, ErrUnresolvedReference)
+	assert.ErrorContains(t, err, "$kusion_path.hashicorp:aws:aws_vpc:vpc.id")
+}
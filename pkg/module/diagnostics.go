@@ -0,0 +1,147 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+// Severity represents the severity of a Diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic represents a problem or a notice reported by a module, such as an invalid field
+// of the devConfig or a deprecated platformConfig option.
+type Diagnostic struct {
+	// Severity represents the severity of the diagnostic
+	Severity Severity `json:"severity" yaml:"severity"`
+	// Summary is a short description of the diagnostic
+	Summary string `json:"summary" yaml:"summary"`
+	// Detail is an optional detailed description of the diagnostic
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	// Attribute is the path of the config field the diagnostic refers to, e.g. devConfig.replicas
+	Attribute string `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+}
+
+// Diagnostics is a list of Diagnostic.
+type Diagnostics []Diagnostic
+
+// ErrorDiagnostic returns a diagnostic with the error severity.
+func ErrorDiagnostic(attribute, summary, detail string) Diagnostic {
+	return Diagnostic{Severity: SeverityError, Summary: summary, Detail: detail, Attribute: attribute}
+}
+
+// WarningDiagnostic returns a diagnostic with the warning severity.
+func WarningDiagnostic(attribute, summary, detail string) Diagnostic {
+	return Diagnostic{Severity: SeverityWarning, Summary: summary, Detail: detail, Attribute: attribute}
+}
+
+// InfoDiagnostic returns a diagnostic with the info severity.
+func InfoDiagnostic(attribute, summary, detail string) Diagnostic {
+	return Diagnostic{Severity: SeverityInfo, Summary: summary, Detail: detail, Attribute: attribute}
+}
+
+// String returns a human-readable representation of the diagnostic.
+func (d Diagnostic) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(d.Severity))
+	if d.Attribute != "" {
+		sb.WriteString(": " + d.Attribute)
+	}
+	sb.WriteString(": " + d.Summary)
+	if d.Detail != "" {
+		sb.WriteString(". " + d.Detail)
+	}
+	return sb.String()
+}
+
+// HasErrors returns whether any diagnostic has the error severity.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err combines all diagnostics with the error severity into one error, returns nil if there is none.
+func (ds Diagnostics) Err() error {
+	var msgs []string
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			msgs = append(msgs, d.String())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "\n"))
+}
+
+// ToProto converts the diagnostics into the proto format.
+func (ds Diagnostics) ToProto() []*proto.Diagnostic {
+	if len(ds) == 0 {
+		return nil
+	}
+	out := make([]*proto.Diagnostic, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, &proto.Diagnostic{
+			Severity:  d.Severity.toProto(),
+			Summary:   d.Summary,
+			Detail:    d.Detail,
+			Attribute: d.Attribute,
+		})
+	}
+	return out
+}
+
+// DiagnosticsFromProto converts the diagnostics in the proto response back into Diagnostics.
+func DiagnosticsFromProto(pds []*proto.Diagnostic) Diagnostics {
+	if len(pds) == 0 {
+		return nil
+	}
+	out := make(Diagnostics, 0, len(pds))
+	for _, pd := range pds {
+		out = append(out, Diagnostic{
+			Severity:  severityFromProto(pd.GetSeverity()),
+			Summary:   pd.GetSummary(),
+			Detail:    pd.GetDetail(),
+			Attribute: pd.GetAttribute(),
+		})
+	}
+	return out
+}
+
+func (s Severity) toProto() proto.Diagnostic_Severity {
+	switch s {
+	case SeverityError:
+		return proto.Diagnostic_ERROR
+	case SeverityWarning:
+		return proto.Diagnostic_WARNING
+	case SeverityInfo:
+		return proto.Diagnostic_INFO
+	default:
+		return proto.Diagnostic_INVALID
+	}
+}
+
+func severityFromProto(s proto.Diagnostic_Severity) Severity {
+	switch s {
+	case proto.Diagnostic_ERROR:
+		return SeverityError
+	case proto.Diagnostic_WARNING:
+		return SeverityWarning
+	case proto.Diagnostic_INFO:
+		return SeverityInfo
+	default:
+		// unknown severities are treated as errors to never hide a problem
+		return SeverityError
+	}
+}
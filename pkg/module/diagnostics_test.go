@@ -0,0 +1,42 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+func TestDiagnostics(t *testing.T) {
+	ds := Diagnostics{
+		WarningDiagnostic("platformConfig.size", "size is deprecated", "use instanceType instead"),
+		InfoDiagnostic("", "using default region", ""),
+	}
+	assert.False(t, ds.HasErrors())
+	assert.NoError(t, ds.Err())
+
+	ds = append(ds,
+		ErrorDiagnostic("devConfig.replicas", "replicas must be positive", ""),
+		ErrorDiagnostic("devConfig.image", "image is required", ""),
+	)
+	assert.True(t, ds.HasErrors())
+	assert.EqualError(t, ds.Err(), "error: devConfig.replicas: replicas must be positive\nerror: devConfig.image: image is required")
+}
+
+func TestDiagnosticsProtoRoundTrip(t *testing.T) {
+	ds := Diagnostics{
+		ErrorDiagnostic("devConfig.replicas", "replicas must be positive", "got -1"),
+		WarningDiagnostic("platformConfig.size", "size is deprecated", ""),
+		InfoDiagnostic("", "using default region", ""),
+	}
+	pds := ds.ToProto()
+	assert.Equal(t, proto.Diagnostic_ERROR, pds[0].Severity)
+	assert.Equal(t, proto.Diagnostic_WARNING, pds[1].Severity)
+	assert.Equal(t, proto.Diagnostic_INFO, pds[2].Severity)
+	assert.Equal(t, ds, DiagnosticsFromProto(pds))
+
+	assert.Nil(t, Diagnostics(nil).ToProto())
+	assert.Nil(t, DiagnosticsFromProto(nil))
+	assert.Equal(t, SeverityError, DiagnosticsFromProto([]*proto.Diagnostic{{Summary: "unknown"}})[0].Severity)
+}
@@ -31,11 +31,9 @@ func TestNamespaceResource(t *testing.T) {
 					"apiVersion": "v1",
 					"kind":       "Namespace",
 					"metadata": map[string]interface{}{
-						"creationTimestamp": nil,
-						"name":              "testNS",
+						"name": "testNS",
 					},
-					"spec":   make(map[string]interface{}),
-					"status": make(map[string]interface{}),
+					"spec": make(map[string]interface{}),
 				},
 				DependsOn: nil,
 				Extensions: map[string]interface{}{
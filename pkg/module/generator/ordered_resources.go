@@ -3,43 +3,24 @@ package generator
 import (
 	"context"
 	"errors"
+	"slices"
 
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
-)
 
-type resource v1.Resource
+	"kusionstack.io/kusion-module-framework/pkg/graph"
+)
 
 // DefaultOrderedKinds provides the default order of Kubernetes resource kinds.
-var DefaultOrderedKinds = []string{
-	"Namespace",
-	"ResourceQuota",
-	"StorageClass",
-	"CustomResourceDefinition",
-	"ServiceAccount",
-	"PodSecurityPolicy",
-	"Role",
-	"ClusterRole",
-	"RoleBinding",
-	"ClusterRoleBinding",
-	"ConfigMap",
-	"Secret",
-	"Endpoints",
-	"Service",
-	"LimitRange",
-	"PriorityClass",
-	"PersistentVolume",
-	"PersistentVolumeClaim",
-	"Deployment",
-	"StatefulSet",
-	"CronJob",
-	"PodDisruptionBudget",
-	"MutatingWebhookConfiguration",
-	"ValidatingWebhookConfiguration",
-}
+var DefaultOrderedKinds = slices.Clone(graph.DefaultOrderedKinds)
 
 // OrderedResources returns a list of Kusion Resources with the injected `dependsOn`
 // in a specified order.
+//
+// The dependencies are built by the dependency graph, see graph.New: each Kubernetes resource
+// depends on the resources of the closest earlier kind in its namespace and in the cluster scope,
+// the "$kusion_path" references of both Kubernetes and Terraform resources are depended on, and the
+// redundant dependencies are removed. The resources keep their order, and an error is returned if
+// the dependencies contain a cycle.
 func OrderedResources(ctx context.Context, resources v1.Resources, orderedKinds []string) (v1.Resources, error) {
 	if len(orderedKinds) == 0 {
 		orderedKinds = DefaultOrderedKinds
@@ -49,64 +30,26 @@ func OrderedResources(ctx context.Context, resources v1.Resources, orderedKinds
 		return nil, errors.New("empty resources")
 	}
 
-	for i := 0; i < len(resources); i++ {
-		// Continue if the resource is not a Kubernetes resource.
-		if resources[i].Type != v1.Kubernetes {
-			continue
-		}
-
-		// Inject dependsOn of the resource.
-		r := (*resource)(&resources[i])
-		r.injectDependsOn(orderedKinds, resources)
-		resources[i] = v1.Resource(*r)
+	g, err := graph.New(resources, &graph.Options{OrderedKinds: orderedKinds})
+	if err != nil {
+		return nil, err
 	}
-
-	return resources, nil
-}
-
-// kubernetesKind returns the kubernetes kind of the given resource.
-func (r resource) kubernetesKind() string {
-	u := &unstructured.Unstructured{}
-	u.SetUnstructuredContent(r.Attributes)
-	return u.GetKind()
-}
-
-// injectDependsOn injects all dependsOn relationships for the given resource and dependent kinds.
-func (r *resource) injectDependsOn(orderedKinds []string, rs []v1.Resource) {
-	kinds := r.findDependKinds(orderedKinds)
-	for _, kind := range kinds {
-		drs := findDependResources(kind, rs)
-		r.appendDependsOn(drs)
+	if err = g.Reduce(); err != nil {
+		return nil, err
 	}
-}
-
-// appendDependsOn injects dependsOn relationships for the given resource and dependent resources.
-func (r *resource) appendDependsOn(dependResources []*v1.Resource) {
-	for _, dr := range dependResources {
-		r.DependsOn = append(r.DependsOn, dr.ID)
+	sorted, err := g.Resources()
+	if err != nil {
+		return nil, err
 	}
-}
 
-// findDependKinds returns the dependent resource kinds for the specified kind.
-func (r *resource) findDependKinds(orderedKinds []string) []string {
-	curKind := r.kubernetesKind()
-	dependKinds := make([]string, 0)
-	for _, previousKind := range orderedKinds {
-		if curKind == previousKind {
-			break
-		}
-		dependKinds = append(dependKinds, previousKind)
+	// Inject dependsOn of the resources in their original order.
+	dependsOn := make(map[string][]string, len(sorted))
+	for _, r := range sorted {
+		dependsOn[r.ID] = r.DependsOn
 	}
-	return dependKinds
-}
-
-// findDependResources returns the dependent resources of the specified kind.
-func findDependResources(dependKind string, rs []v1.Resource) []*v1.Resource {
-	var dependResources []*v1.Resource
-	for i := 0; i < len(rs); i++ {
-		if resource(rs[i]).kubernetesKind() == dependKind {
-			dependResources = append(dependResources, &rs[i])
-		}
+	for i := range resources {
+		resources[i].DependsOn = dependsOn[resources[i].ID]
 	}
-	return dependResources
+
+	return resources, nil
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/graph"
 )
 
 var (
@@ -92,7 +94,6 @@ func TestOrderedResources(t *testing.T) {
 					Type:       v1.Kubernetes,
 					Attributes: fakeDeployment,
 					DependsOn: []string{
-						"v1:Namespace:foo",
 						"v1:Service:foo:bar",
 					},
 				},
@@ -138,11 +139,66 @@ func TestOrderedResources(t *testing.T) {
 					ID:         "v1:Namespace:foo",
 					Type:       v1.Kubernetes,
 					Attributes: fakeNamespace,
-					DependsOn: []string{
-						"apps/v1:Deployment:foo:bar",
-						"v1:Service:foo:bar",
+				},
+			},
+			errExpected: false,
+		},
+		{
+			name: "resources in different namespaces and terraform resources",
+			resources: v1.Resources{
+				{
+					ID:         "apps/v1:Deployment:foo:bar",
+					Type:       v1.Kubernetes,
+					Attributes: fakeDeployment,
+					DependsOn:  []string{"external"},
+				},
+				{
+					ID:   "v1:Namespace:baz",
+					Type: v1.Kubernetes,
+					Attributes: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Namespace",
+						"metadata":   map[string]interface{}{"name": "baz"},
 					},
 				},
+				{
+					ID:         "hashicorp:random:random_password:bar",
+					Type:       v1.Terraform,
+					Attributes: map[string]interface{}{"keepers": "$kusion_path.v1:Service:foo:bar.metadata.name"},
+				},
+				{
+					ID:         "v1:Service:foo:bar",
+					Type:       v1.Kubernetes,
+					Attributes: fakeService,
+				},
+			},
+			resExpected: v1.Resources{
+				{
+					ID:         "apps/v1:Deployment:foo:bar",
+					Type:       v1.Kubernetes,
+					Attributes: fakeDeployment,
+					DependsOn:  []string{"v1:Service:foo:bar", "external"},
+				},
+				{
+					ID:   "v1:Namespace:baz",
+					Type: v1.Kubernetes,
+					Attributes: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Namespace",
+						"metadata":   map[string]interface{}{"name": "baz"},
+					},
+				},
+				{
+					ID:         "hashicorp:random:random_password:bar",
+					Type:       v1.Terraform,
+					Attributes: map[string]interface{}{"keepers": "$kusion_path.v1:Service:foo:bar.metadata.name"},
+					DependsOn:  []string{"v1:Service:foo:bar"},
+				},
+				{
+					ID:         "v1:Service:foo:bar",
+					Type:       v1.Kubernetes,
+					Attributes: fakeService,
+				},
 			},
 			errExpected: false,
 		},
@@ -159,76 +215,11 @@ func TestOrderedResources(t *testing.T) {
 	}
 }
 
-func TestResourceKind(t *testing.T) {
-	r := &resource{
-		Type: v1.Kubernetes,
-		Attributes: map[string]interface{}{
-			"kind": "Deployment",
-		},
-	}
-
-	expected := "Deployment"
-	actual := r.kubernetesKind()
-
-	assert.Equal(t, expected, actual)
-}
-
-func TestInjectAllDependsOn(t *testing.T) {
-	resources := genOldResources()
-	dependKinds := []string{"Namespace"}
-
-	expected := []string{"v1:Namespace:foo"}
-	actual := resource((*resources)[0])
-	actual.injectDependsOn(dependKinds, *resources)
-
-	assert.Equal(t, expected, actual.DependsOn)
-}
-
-func TestFindDependKinds(t *testing.T) {
-	r := &resource{
-		Type: v1.Kubernetes,
-		Attributes: map[string]interface{}{
-			"kind": "Deployment",
-		},
-	}
-
-	expected := []string{
-		"Namespace",
-		"ResourceQuota",
-		"StorageClass",
-		"CustomResourceDefinition",
-		"ServiceAccount",
-		"PodSecurityPolicy",
-		"Role",
-		"ClusterRole",
-		"RoleBinding",
-		"ClusterRoleBinding",
-		"ConfigMap",
-		"Secret",
-		"Endpoints",
-		"Service",
-		"LimitRange",
-		"PriorityClass",
-		"PersistentVolume",
-		"PersistentVolumeClaim",
-	}
-	actual := r.findDependKinds(DefaultOrderedKinds)
-
-	assert.Equal(t, expected, actual)
-}
-
-func TestFindDependResources(t *testing.T) {
-	dependKind := "Namespace"
-	resources := genOldResources()
-
-	expected := []*v1.Resource{
-		{
-			ID:         "v1:Namespace:foo",
-			Type:       v1.Kubernetes,
-			Attributes: fakeNamespace,
-		},
-	}
-	actual := findDependResources(dependKind, *resources)
+func TestDefaultOrderedKinds(t *testing.T) {
+	kinds := DefaultOrderedKinds[0]
+	t.Cleanup(func() { DefaultOrderedKinds[0] = kinds })
 
-	assert.Equal(t, expected, actual)
+	// the default kinds are not shared with the graph package
+	DefaultOrderedKinds[0] = "Foo"
+	assert.Equal(t, "Namespace", graph.DefaultOrderedKinds[0])
 }
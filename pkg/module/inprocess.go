@@ -0,0 +1,139 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+var (
+	inProcessMu      sync.RWMutex
+	inProcessModules = map[string]FrameworkModule{}
+)
+
+// RegisterInProcess registers a FrameworkModule under the module key, e.g. "kusionstack/mysql@v0.1.0".
+// NewPlugin returns a Plugin calling the registered module in the same process instead of launching
+// the plugin binary, while the requests and responses still go through the proto marshaling.
+// It is intended for integration tests and single-binary distributions.
+func RegisterInProcess(key string, impl FrameworkModule) error {
+	if _, _, _, err := parseModuleKey(key); err != nil {
+		return err
+	}
+	if impl == nil {
+		return fmt.Errorf("in-process module %s can not be nil", key)
+	}
+
+	inProcessMu.Lock()
+	defer inProcessMu.Unlock()
+	if _, ok := inProcessModules[key]; ok {
+		return fmt.Errorf("in-process module %s is already registered", key)
+	}
+	inProcessModules[key] = impl
+	return nil
+}
+
+// UnregisterInProcess removes the in-process module registered under the module key.
+func UnregisterInProcess(key string) {
+	inProcessMu.Lock()
+	defer inProcessMu.Unlock()
+	delete(inProcessModules, key)
+}
+
+func lookupInProcess(key string) (FrameworkModule, bool) {
+	inProcessMu.RLock()
+	defer inProcessMu.RUnlock()
+	impl, ok := inProcessModules[key]
+	return impl, ok
+}
+
+// inProcessClient is a proto.ModuleClient calling the GRPCServer in the same process. It behaves
+// like a gRPC connection: messages are marshaled, outgoing metadata becomes incoming metadata,
+// errors are converted into gRPC status and a call returns as soon as its context is done.
+type inProcessClient struct {
+	server *GRPCServer
+}
+
+// NewInProcessModule returns a Module calling the FrameworkModule in the same process through the
+// same proto marshaling and FrameworkModuleWrapper as a plugin binary.
+func NewInProcessModule(impl FrameworkModule) Module {
+	return &GRPCClient{client: &inProcessClient{
+		server: &GRPCServer{Impl: &FrameworkModuleWrapper{Module: impl}},
+	}}
+}
+
+func (c *inProcessClient) Generate(ctx context.Context, in *proto.GeneratorRequest, _ ...grpc.CallOption) (*proto.GeneratorResponse, error) {
+	return invokeInProcess(ctx, in, c.server.Generate)
+}
+
+func (c *inProcessClient) Validate(ctx context.Context, in *proto.GeneratorRequest, _ ...grpc.CallOption) (*proto.ValidateResponse, error) {
+	return invokeInProcess(ctx, in, c.server.Validate)
+}
+
+func (c *inProcessClient) GetSchema(ctx context.Context, in *proto.SchemaRequest, _ ...grpc.CallOption) (*proto.SchemaResponse, error) {
+	return invokeInProcess(ctx, in, c.server.GetSchema)
+}
+
+func (c *inProcessClient) GetCapabilities(ctx context.Context, in *proto.CapabilitiesRequest, _ ...grpc.CallOption) (*proto.CapabilitiesResponse, error) {
+	return invokeInProcess(ctx, in, c.server.GetCapabilities)
+}
+
+// invokeInProcess calls the server method with a copy of the request which has been through the proto
+// marshaling, and returns a marshaled copy of the response.
+func invokeInProcess[Req, Res protobuf.Message](ctx context.Context, in Req,
+	method func(context.Context, Req) (Res, error),
+) (Res, error) {
+	var zero Res
+	req, err := roundTrip(in)
+	if err != nil {
+		return zero, status.Errorf(codes.Internal, "marshal in-process request failed: %v", err)
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	serverCtx, cancel := context.WithCancel(metadata.NewIncomingContext(context.WithoutCancel(ctx), md))
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		serverCtx, cancel = context.WithDeadline(serverCtx, deadline)
+		defer cancel()
+	}
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	type result struct {
+		res Res
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := method(serverCtx, req)
+		done <- result{res: res, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return zero, status.Convert(r.err).Err()
+		}
+		return roundTrip(r.res)
+	case <-ctx.Done():
+		return zero, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// roundTrip marshals and unmarshals the message the way it goes through the wire.
+func roundTrip[M protobuf.Message](m M) (M, error) {
+	out := m.ProtoReflect().New().Interface().(M)
+	data, err := protobuf.Marshal(m)
+	if err != nil {
+		return out, err
+	}
+	err = protobuf.Unmarshal(data, out)
+	return out, err
+}
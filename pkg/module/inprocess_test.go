@@ -0,0 +1,71 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+	"kusionstack.io/kusion-module-framework/pkg/util/kfile"
+)
+
+func TestRegisterInProcess(t *testing.T) {
+	key := "kusionstack/mock@v0.1.0"
+	require.NoError(t, RegisterInProcess(key, &mockFrameworkModule{}))
+	defer UnregisterInProcess(key)
+
+	assert.Error(t, RegisterInProcess(key, &mockFrameworkModule{}))
+	assert.Error(t, RegisterInProcess("invalid-key", &mockFrameworkModule{}))
+	assert.Error(t, RegisterInProcess("kusionstack/nil@v0.1.0", nil))
+}
+
+func TestInProcessPlugin(t *testing.T) {
+	key := "kusionstack/mock@v0.1.0"
+	require.NoError(t, RegisterInProcess(key, &mockValidatorModule{}))
+	defer UnregisterInProcess(key)
+
+	p, err := NewPlugin(key, t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "kusionstack-mock", p.ModuleName)
+	assert.True(t, p.Supports(FeatureValidate))
+
+	req := &proto.GeneratorRequest{
+		Project:        "testProject",
+		Stack:          "testStack",
+		App:            "testApp",
+		Workload:       wl,
+		DevConfig:      []byte(`{"replicas":1}`),
+		PlatformConfig: []byte(`{"type":"aws"}`),
+	}
+	valid, err := p.Module.Validate(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, valid.Valid)
+
+	resp, err := p.Module.Generate(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Resources, 1)
+	var res v1.Resource
+	require.NoError(t, yaml.Unmarshal(resp.Resources[0], &res))
+	assert.Equal(t, "mock-resource", res.ID)
+
+	assert.NoError(t, p.KillPluginClient())
+}
+
+func TestInProcessPluginErrors(t *testing.T) {
+	t.Setenv(kfile.EnvKusionHome, t.TempDir())
+	key := "kusionstack/panic@v0.1.0"
+	require.NoError(t, RegisterInProcess(key, &mockPanicModule{}))
+	defer UnregisterInProcess(key)
+
+	p, err := NewPlugin(key, "")
+	require.NoError(t, err)
+	_, err = p.Module.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	var pe *PanicError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, "nil map", pe.Value)
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 
 	"github.com/hashicorp/go-plugin"
-	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"kusionstack.io/kusion-module-framework/pkg/module/proto"
 )
@@ -15,6 +16,9 @@ const PluginKey = "module-default"
 // Module is the interface that we're exposing as a kusion module plugin.
 type Module interface {
 	Generate(ctx context.Context, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error)
+	Validate(ctx context.Context, req *proto.GeneratorRequest) (*proto.ValidateResponse, error)
+	GetSchema(ctx context.Context, req *proto.SchemaRequest) (*proto.SchemaResponse, error)
+	GetCapabilities(ctx context.Context, req *proto.CapabilitiesRequest) (*proto.CapabilitiesResponse, error)
 }
 
 type GRPCClient struct {
@@ -22,7 +26,46 @@ type GRPCClient struct {
 }
 
 func (c *GRPCClient) Generate(ctx context.Context, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error) {
-	return c.client.Generate(ctx, req)
+	res, err := c.client.Generate(ctx, req)
+	if err != nil {
+		return nil, panicErrorFromStatus(err)
+	}
+	return res, nil
+}
+
+func (c *GRPCClient) Validate(ctx context.Context, req *proto.GeneratorRequest) (*proto.ValidateResponse, error) {
+	res, err := c.client.Validate(ctx, req)
+	if status.Code(err) == codes.Unimplemented {
+		// module binaries built with an older framework don't serve Validate, treat their inputs as valid
+		return &proto.ValidateResponse{Valid: true}, nil
+	}
+	if err != nil {
+		return nil, panicErrorFromStatus(err)
+	}
+	return res, nil
+}
+
+func (c *GRPCClient) GetSchema(ctx context.Context, req *proto.SchemaRequest) (*proto.SchemaResponse, error) {
+	res, err := c.client.GetSchema(ctx, req)
+	if status.Code(err) == codes.Unimplemented {
+		// module binaries built with an older framework don't publish schemas
+		return &proto.SchemaResponse{}, nil
+	}
+	if err != nil {
+		return nil, panicErrorFromStatus(err)
+	}
+	return res, nil
+}
+
+func (c *GRPCClient) GetCapabilities(ctx context.Context, req *proto.CapabilitiesRequest) (*proto.CapabilitiesResponse, error) {
+	res, err := c.client.GetCapabilities(ctx, req)
+	if status.Code(err) == codes.Unimplemented {
+		return LegacyCapabilities().ToProto(), nil
+	}
+	if err != nil {
+		return nil, panicErrorFromStatus(err)
+	}
+	return res, nil
 }
 
 type GRPCServer struct {
@@ -31,17 +74,34 @@ type GRPCServer struct {
 	proto.UnimplementedModuleServer
 }
 
+// Generate calls the module implementation. A panic of the module is recovered and sent to the host
+// as a PanicError, so a panicking module never looks like a module that generated nothing.
 func (s *GRPCServer) Generate(ctx context.Context, req *proto.GeneratorRequest) (res *proto.GeneratorResponse, err error) {
-	defer func() {
-		if e := recover(); e != nil {
-			err = errors.WithStack(err)
-			res = &proto.GeneratorResponse{}
-		}
-	}()
+	defer recoverPanic(ctx, "Generate", &err)
+	ctx, cancel := withModuleDeadline(ctx)
+	defer cancel()
 	res, err = s.Impl.Generate(ctx, req)
 	return
 }
 
+func (s *GRPCServer) Validate(ctx context.Context, req *proto.GeneratorRequest) (res *proto.ValidateResponse, err error) {
+	defer recoverPanic(ctx, "Validate", &err)
+	res, err = s.Impl.Validate(ctx, req)
+	return
+}
+
+func (s *GRPCServer) GetSchema(ctx context.Context, req *proto.SchemaRequest) (res *proto.SchemaResponse, err error) {
+	defer recoverPanic(ctx, "GetSchema", &err)
+	res, err = s.Impl.GetSchema(ctx, req)
+	return
+}
+
+func (s *GRPCServer) GetCapabilities(ctx context.Context, req *proto.CapabilitiesRequest) (res *proto.CapabilitiesResponse, err error) {
+	defer recoverPanic(ctx, "GetCapabilities", &err)
+	res, err = s.Impl.GetCapabilities(ctx, req)
+	return
+}
+
 type GRPCPlugin struct {
 	// GRPCPlugin must still implement the Plugin interface
 	plugin.Plugin
@@ -0,0 +1,196 @@
+package module
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources/kubernetes"
+)
+
+// KustomizationKey is the key of the kustomization in the PlatformConfig, which is applied to the
+// Kubernetes resources generated by the module. The key is removed from the PlatformConfig before
+// the request is passed to the module. The key is namespaced so it doesn't collide with the
+// configuration of the modules. The kustomization is a map, or a string of YAML, e.g.
+//
+//	kusion.io/kustomization:
+//	  commonLabels:
+//	    org: example
+//	  images:
+//	    - name: nginx
+//	      newName: registry.example.com/nginx
+//	  patches:
+//	    - target:
+//	        kind: Deployment
+//	      patch: |-
+//	        - op: add
+//	          path: /spec/revisionHistoryLimit
+//	          value: 3
+const KustomizationKey = "kusion.io/kustomization"
+
+// kustomizationFields are the fields allowed in the kustomization. The fields loading other files or
+// running plugins are not allowed, neither are the fields changing the names and namespaces, which
+// would change the resource IDs.
+var kustomizationFields = map[string]bool{
+	"commonLabels":          true,
+	"labels":                true,
+	"commonAnnotations":     true,
+	"images":                true,
+	"replicas":              true,
+	"patches":               true,
+	"patchesStrategicMerge": true,
+	"patchesJson6902":       true,
+}
+
+const kustomizeDir = "/kusion"
+
+// ErrInvalidKustomization means the kustomization in the PlatformConfig can't be applied.
+var ErrInvalidKustomization = errors.New("invalid kustomization")
+
+// takeKustomization removes the kustomization from the PlatformConfig of the request, validates it
+// and returns it.
+func takeKustomization(request *GeneratorRequest) (map[string]any, error) {
+	value, ok := request.PlatformConfig[KustomizationKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(request.PlatformConfig, KustomizationKey)
+
+	var kustomization map[string]any
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if err := yaml.Unmarshal([]byte(v), &kustomization); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKustomization, err)
+		}
+	case map[string]any:
+		kustomization = v
+	case v1.GenericConfig:
+		kustomization = v
+	default:
+		return nil, fmt.Errorf("%w: expected a map or a string, got %T", ErrInvalidKustomization, value)
+	}
+	if err := validateKustomization(kustomization); err != nil {
+		return nil, err
+	}
+	return kustomization, nil
+}
+
+// validateKustomization checks that the kustomization only has the allowed fields.
+func validateKustomization(kustomization map[string]any) error {
+	var fields []string
+	for field := range kustomization {
+		if field != "apiVersion" && field != "kind" && !kustomizationFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) != 0 {
+		sort.Strings(fields)
+		return fmt.Errorf("%w: unsupported fields %s", ErrInvalidKustomization, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// applyKustomization applies the kustomization to the Kubernetes resources in place. The other
+// resources, the IDs, the dependsOn and the extensions of the resources are kept.
+func applyKustomization(kustomization map[string]any, resources []v1.Resource) error {
+	if len(kustomization) == 0 {
+		return nil
+	}
+	if err := validateKustomization(kustomization); err != nil {
+		return err
+	}
+	k := map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  []any{"resources.yaml"},
+	}
+	for field, value := range kustomization {
+		if field != "apiVersion" && field != "kind" {
+			k[field] = value
+		}
+	}
+
+	// the index of each Kubernetes resource by its identity
+	index := map[string]int{}
+	var manifests bytes.Buffer
+	for i, r := range resources {
+		if r.Type != v1.Kubernetes {
+			continue
+		}
+		out, err := yaml.Marshal(r.Attributes)
+		if err != nil {
+			return fmt.Errorf("marshal resource %s failed: %w", r.ID, err)
+		}
+		// the attributes may be decoded by yaml.v2, in which the nested maps have interface keys
+		var obj map[string]any
+		if err = yaml.Unmarshal(out, &obj); err != nil {
+			return fmt.Errorf("unmarshal resource %s failed: %w", r.ID, err)
+		}
+		id := objectID(&unstructured.Unstructured{Object: obj})
+		if _, ok := index[id]; ok {
+			return fmt.Errorf("%w: duplicate Kubernetes object %s", ErrInvalidKustomization, id)
+		}
+		index[id] = i
+		manifests.WriteString("---\n")
+		manifests.Write(out)
+	}
+	if len(index) == 0 {
+		return nil
+	}
+
+	fs := filesys.MakeFsInMemory()
+	kustomizationFile, err := yaml.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKustomization, err)
+	}
+	if err = fs.WriteFile(kustomizeDir+"/kustomization.yaml", kustomizationFile); err != nil {
+		return err
+	}
+	if err = fs.WriteFile(kustomizeDir+"/resources.yaml", manifests.Bytes()); err != nil {
+		return err
+	}
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, kustomizeDir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKustomization, err)
+	}
+
+	kustomized := map[string]map[string]any{}
+	for _, res := range resMap.Resources() {
+		attributes, err := res.Map()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidKustomization, err)
+		}
+		u := &unstructured.Unstructured{Object: attributes}
+		id := objectID(u)
+		if _, ok := index[id]; !ok {
+			return fmt.Errorf("%w: object %s is not generated by the module, the kustomization must not add or rename objects", ErrInvalidKustomization, id)
+		}
+		kustomized[id] = attributes
+	}
+	for id, i := range index {
+		attributes, ok := kustomized[id]
+		if !ok {
+			return fmt.Errorf("%w: object %s is removed by the kustomization", ErrInvalidKustomization, id)
+		}
+		resources[i].Attributes = attributes
+	}
+	return nil
+}
+
+// objectID returns the identity of the Kubernetes object in the format of the Kusion resource IDs.
+func objectID(u *unstructured.Unstructured) string {
+	return kubernetes.ToKusionResourceID(schema.FromAPIVersionAndKind(u.GetAPIVersion(), u.GetKind()),
+		metav1.ObjectMeta{Namespace: u.GetNamespace(), Name: u.GetName()})
+}
@@ -0,0 +1,177 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+func kustomizeResources() []v1.Resource {
+	return []v1.Resource{
+		{
+			ID:   "apps/v1:Deployment:foo:web",
+			Type: v1.Kubernetes,
+			Attributes: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web", "namespace": "foo"},
+				"spec": map[string]any{
+					"replicas": 1,
+					"template": map[string]any{
+						"spec": map[string]any{
+							"containers": []any{map[string]any{"name": "web", "image": "nginx:1.25"}},
+						},
+					},
+				},
+			},
+			DependsOn:  []string{"v1:Namespace:foo"},
+			Extensions: map[string]any{v1.ResourceExtensionGVK: "apps/v1, Kind=Deployment"},
+		},
+		{
+			ID:         "v1:Namespace:foo",
+			Type:       v1.Kubernetes,
+			Attributes: map[string]any{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]any{"name": "foo"}},
+			Extensions: map[string]any{v1.ResourceExtensionGVK: "/v1, Kind=Namespace"},
+		},
+		{
+			ID:         "hashicorp:aws:aws_s3_bucket:foo",
+			Type:       v1.Terraform,
+			Attributes: map[string]any{"bucket": "foo"},
+		},
+	}
+}
+
+func TestApplyKustomization(t *testing.T) {
+	var kustomization map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(`
+commonLabels:
+  org: example
+images:
+  - name: nginx
+    newName: registry.example.com/nginx
+patches:
+  - target:
+      kind: Deployment
+    patch: |-
+      - op: add
+        path: /spec/revisionHistoryLimit
+        value: 3
+`), &kustomization))
+
+	resources := kustomizeResources()
+	require.NoError(t, applyKustomization(kustomization, resources))
+
+	deployment := resources[0]
+	assert.Equal(t, "apps/v1:Deployment:foo:web", deployment.ID)
+	assert.Equal(t, []string{"v1:Namespace:foo"}, deployment.DependsOn)
+	assert.Equal(t, "apps/v1, Kind=Deployment", deployment.Extensions[v1.ResourceExtensionGVK])
+	metadata := deployment.Attributes["metadata"].(map[string]any)
+	assert.Equal(t, map[string]any{"org": "example"}, metadata["labels"])
+	spec := deployment.Attributes["spec"].(map[string]any)
+	assert.Equal(t, 3, spec["revisionHistoryLimit"])
+	assert.Equal(t, 1, spec["replicas"])
+	containers := spec["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)
+	assert.Equal(t, "registry.example.com/nginx:1.25", containers[0].(map[string]any)["image"])
+
+	namespace := resources[1]
+	assert.Equal(t, map[string]any{"org": "example"}, namespace.Attributes["metadata"].(map[string]any)["labels"])
+	assert.Equal(t, map[string]any{"bucket": "foo"}, resources[2].Attributes)
+}
+
+func TestApplyKustomizationErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		kustomization map[string]any
+		err           string
+	}{
+		{
+			name:          "unsupported fields",
+			kustomization: map[string]any{"resources": []any{"https://example.com/a.yaml"}, "namePrefix": "a-"},
+			err:           "invalid kustomization: unsupported fields namePrefix, resources",
+		},
+		{
+			name: "rename",
+			kustomization: map[string]any{"patches": []any{map[string]any{
+				"target": map[string]any{"kind": "Namespace"},
+				"patch":  `[{"op": "replace", "path": "/metadata/name", "value": "bar"}]`,
+			}}},
+			err: "the kustomization must not add or rename objects",
+		},
+		{
+			name: "invalid patch",
+			kustomization: map[string]any{"patches": []any{map[string]any{
+				"target": map[string]any{"kind": "Deployment"},
+				"patch":  `[{"op": "remove", "path": "/spec/missing"}]`,
+			}}},
+			err: "invalid kustomization",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, applyKustomization(tt.kustomization, kustomizeResources()), tt.err)
+		})
+	}
+}
+
+type mockPlatformConfigModule struct {
+	platformConfig v1.GenericConfig
+}
+
+func (m *mockPlatformConfigModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	m.platformConfig = req.PlatformConfig
+	return &GeneratorResponse{Resources: kustomizeResources()[:2]}, nil
+}
+
+func TestGenerateWithKustomization(t *testing.T) {
+	m := &mockPlatformConfigModule{}
+	fmw := &FrameworkModuleWrapper{Module: m}
+	resp, err := fmw.Generate(context.Background(), &proto.GeneratorRequest{
+		Project: "testProject",
+		PlatformConfig: []byte(`replicas: 2
+kustomization: keep
+kusion.io/kustomization: |
+  commonAnnotations:
+    owner: platform
+`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"replicas": 2, "kustomization": "keep"}, m.platformConfig)
+
+	var deployment v1.Resource
+	require.NoError(t, yaml.Unmarshal(resp.Resources[0], &deployment))
+	assert.Equal(t, map[string]any{"owner": "platform"}, deployment.Attributes["metadata"].(map[string]any)["annotations"])
+
+	_, err = fmw.Generate(context.Background(), &proto.GeneratorRequest{
+		Project:        "testProject",
+		PlatformConfig: []byte(`kusion.io/kustomization: 1`),
+	})
+	assert.ErrorIs(t, err, ErrInvalidKustomization)
+}
+
+func TestValidateWithKustomization(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockPlatformConfigModule{}}
+	resp, err := fmw.Validate(context.Background(), &proto.GeneratorRequest{
+		Project: "testProject",
+		PlatformConfig: []byte(`kusion.io/kustomization:
+  namePrefix: a-
+  resources:
+    - https://example.com/a.yaml
+`),
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, []string{"invalid kustomization: unsupported fields namePrefix, resources"}, resp.Errors)
+
+	resp, err = fmw.Validate(context.Background(), &proto.GeneratorRequest{
+		Project:        "testProject",
+		PlatformConfig: []byte("kusion.io/kustomization:\n  commonLabels:\n    org: example\n"),
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+}
@@ -0,0 +1,261 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"kusionstack.io/kusion-module-framework/pkg/log"
+)
+
+// DefaultIdleTimeout is the default duration an unused plugin keeps running before it is killed.
+const DefaultIdleTimeout = 5 * time.Minute
+
+// ErrPluginManagerShutdown is returned when acquiring a plugin from a PluginManager that has been shut down.
+var ErrPluginManagerShutdown = errors.New("plugin manager has been shut down")
+
+// PluginManager caches the running module plugins by module key and working directory, so that
+// a module used by many accessories in a stack only starts one plugin process. Plugins are
+// reference counted and killed after being idle for the idle timeout.
+type PluginManager struct {
+	mu sync.Mutex
+	// plugins are the current plugins by module key and working directory
+	plugins map[pluginID]*managedPlugin
+	// managed are all plugins in use or idle, including the exited ones still referenced
+	managed     map[*Plugin]*managedPlugin
+	idleTimeout time.Duration
+	shutdown    bool
+	// newPlugin starts a new plugin, it is NewPlugin except in tests
+	newPlugin func(key, dir string) (*Plugin, error)
+	// killPlugin kills the plugin process, it is (*Plugin).kill except in tests
+	killPlugin func(p *Plugin)
+	// pluginExited returns whether the plugin process has exited, it is (*Plugin).exited except in tests
+	pluginExited func(p *Plugin) bool
+}
+
+// pluginID identifies a cached plugin.
+type pluginID struct {
+	key string
+	dir string
+}
+
+type managedPlugin struct {
+	plugin *Plugin
+	refs   int
+	// idle is the timer to kill the plugin, which is only set when refs is 0
+	idle *time.Timer
+	// started is closed once the plugin is started, err is the error of starting it
+	started chan struct{}
+	err     error
+}
+
+// signalNotify and signalStop are signal.Notify and signal.Stop except in tests.
+var (
+	signalNotify = signal.Notify
+	signalStop   = signal.Stop
+)
+
+// NewPluginManager creates a PluginManager, a non-positive idleTimeout means DefaultIdleTimeout.
+func NewPluginManager(idleTimeout time.Duration) *PluginManager {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	return &PluginManager{
+		plugins:      make(map[pluginID]*managedPlugin),
+		managed:      make(map[*Plugin]*managedPlugin),
+		idleTimeout:  idleTimeout,
+		newPlugin:    NewPlugin,
+		killPlugin:   (*Plugin).kill,
+		pluginExited: (*Plugin).exited,
+	}
+}
+
+// Acquire returns the running plugin of the module key in the working directory, or starts a new
+// one if there is none. Each Acquire must be paired with a Release once the plugin is not used.
+// Plugins are started without holding the lock, the concurrent Acquire of a plugin being started
+// waits for it.
+func (m *PluginManager) Acquire(key, dir string) (*Plugin, error) {
+	id := pluginID{key: key, dir: dir}
+
+	m.mu.Lock()
+	if m.shutdown {
+		m.mu.Unlock()
+		return nil, ErrPluginManagerShutdown
+	}
+	mp, ok := m.plugins[id]
+	if ok && mp.plugin != nil && m.pluginExited(mp.plugin) {
+		// the plugin process has exited unexpectedly, start a new one
+		log.Warnf("module plugin %s exited, restarting it", key)
+		m.retire(id, mp)
+		ok = false
+	}
+	if ok {
+		if mp.idle != nil {
+			mp.idle.Stop()
+			mp.idle = nil
+		}
+		mp.refs++
+		m.mu.Unlock()
+
+		<-mp.started
+		if mp.err != nil {
+			return nil, mp.err
+		}
+		return mp.plugin, nil
+	}
+	mp = &managedPlugin{refs: 1, started: make(chan struct{})}
+	m.plugins[id] = mp
+	m.mu.Unlock()
+
+	p, err := m.newPlugin(key, dir)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer close(mp.started)
+	if err == nil && m.shutdown {
+		m.killPlugin(p)
+		err = ErrPluginManagerShutdown
+	}
+	if err != nil {
+		mp.err = err
+		if m.plugins[id] == mp {
+			delete(m.plugins, id)
+		}
+		return nil, err
+	}
+	mp.plugin = p
+	m.managed[p] = mp
+	return p, nil
+}
+
+// Release marks the plugin as not used by the caller. The plugin is killed after the idle
+// timeout if it is not acquired again, or at once if it has exited and been replaced. Release
+// does nothing after Shutdown, which has killed all plugins.
+func (m *PluginManager) Release(p *Plugin) error {
+	if p == nil {
+		return fmt.Errorf("plugin is nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shutdown {
+		return nil
+	}
+	mp, ok := m.managed[p]
+	if !ok {
+		return fmt.Errorf("plugin: %s is not managed by the plugin manager", p.key)
+	}
+	if mp.refs == 0 {
+		return fmt.Errorf("plugin: %s is released more times than acquired", p.key)
+	}
+
+	mp.refs--
+	if mp.refs == 0 {
+		id := pluginID{key: p.key, dir: p.dir}
+		if m.plugins[id] != mp {
+			delete(m.managed, p)
+			m.killPlugin(p)
+			return nil
+		}
+		mp.idle = time.AfterFunc(m.idleTimeout, func() {
+			m.expire(id, mp)
+		})
+	}
+	return nil
+}
+
+// retire replaces the exited plugin, which stays managed until all references are released.
+func (m *PluginManager) retire(id pluginID, mp *managedPlugin) {
+	delete(m.plugins, id)
+	if mp.refs == 0 {
+		if mp.idle != nil {
+			mp.idle.Stop()
+		}
+		delete(m.managed, mp.plugin)
+		m.killPlugin(mp.plugin)
+	}
+}
+
+// expire kills the plugin if it is still idle.
+func (m *PluginManager) expire(id pluginID, mp *managedPlugin) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.plugins[id] != mp || mp.refs != 0 {
+		return
+	}
+	delete(m.plugins, id)
+	delete(m.managed, mp.plugin)
+	m.killPlugin(mp.plugin)
+}
+
+// Shutdown kills all plugins no matter whether they are in use, and rejects acquiring new plugins.
+// The plugins being started are killed once they are started.
+func (m *PluginManager) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.shutdown = true
+	for p, mp := range m.managed {
+		if mp.idle != nil {
+			mp.idle.Stop()
+		}
+		m.killPlugin(p)
+	}
+	clear(m.managed)
+	clear(m.plugins)
+}
+
+// ShutdownOnSignals shuts the manager down when one of the signals is received by the host, so
+// that no plugin process outlives the host. The signal is raised again after the shutdown to
+// keep its default behavior. If no signals are given, os.Interrupt and SIGTERM are watched.
+// The returned function stops watching the signals, and returns after they are unregistered.
+func (m *PluginManager) ShutdownOnSignals(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	signalNotify(ch, signals...)
+
+	go func() {
+		defer close(stopped)
+		select {
+		case sig := <-ch:
+			log.Infof("received signal %s, killing all module plugins", sig)
+			m.Shutdown()
+			signalStop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(sig)
+			}
+		case <-done:
+			signalStop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+		<-stopped
+	}
+}
+
+// exited returns whether the plugin process has exited.
+func (p *Plugin) exited() bool {
+	return p.client != nil && p.client.Exited()
+}
+
+// kill kills the plugin process if it is running.
+func (p *Plugin) kill() {
+	if p.client != nil {
+		p.client.Kill()
+	}
+}
@@ -0,0 +1,278 @@
+package module
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlugins records the plugins started and killed by a test plugin manager.
+type testPlugins struct {
+	mu      sync.Mutex
+	started int
+	killed  map[*Plugin]bool
+	exited  map[*Plugin]bool
+}
+
+func (tp *testPlugins) startedCount() int {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.started
+}
+
+func (tp *testPlugins) isKilled(p *Plugin) bool {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return tp.killed[p]
+}
+
+func newTestPluginManager(idleTimeout time.Duration) (*PluginManager, *testPlugins) {
+	tp := &testPlugins{killed: map[*Plugin]bool{}, exited: map[*Plugin]bool{}}
+	m := NewPluginManager(idleTimeout)
+	m.newPlugin = func(key, dir string) (*Plugin, error) {
+		if key == "" {
+			return nil, errors.New("module key can not be empty")
+		}
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		tp.started++
+		return &Plugin{key: key, dir: dir}, nil
+	}
+	m.killPlugin = func(p *Plugin) {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		tp.killed[p] = true
+	}
+	m.pluginExited = func(p *Plugin) bool {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		return tp.exited[p]
+	}
+	return m, tp
+}
+
+func TestPluginManagerAcquire(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+
+	p1, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	p2, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	assert.Same(t, p1, p2)
+
+	p3, err := m.Acquire("kusionstack/mysql@v0.1.0", "/another-stack")
+	require.NoError(t, err)
+	assert.NotSame(t, p1, p3)
+	assert.Equal(t, 2, tp.startedCount())
+
+	_, err = m.Acquire("", "/stack")
+	assert.Error(t, err)
+}
+
+func TestPluginManagerRelease(t *testing.T) {
+	m, tp := newTestPluginManager(10 * time.Millisecond)
+
+	p, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	_, err = m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Release(p))
+	require.NoError(t, m.Release(p))
+	assert.Error(t, m.Release(p))
+	assert.Error(t, m.Release(&Plugin{key: "kusionstack/redis@v0.1.0"}))
+	assert.Error(t, m.Release(nil))
+
+	// the idle plugin is killed after the idle timeout
+	assert.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.plugins) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	assert.Equal(t, 2, tp.startedCount())
+}
+
+func TestPluginManagerReacquireIdlePlugin(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+
+	p1, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	require.NoError(t, m.Release(p1))
+
+	p2, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	assert.Same(t, p1, p2)
+	assert.Nil(t, m.plugins[pluginID{key: p1.key, dir: p1.dir}].idle)
+	assert.Equal(t, 1, tp.startedCount())
+}
+
+func TestPluginManagerShutdown(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+
+	p1, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	p2, err := m.Acquire("kusionstack/redis@v0.1.0", "/stack")
+	require.NoError(t, err)
+	require.NoError(t, m.Release(p2))
+
+	m.Shutdown()
+	assert.Empty(t, m.plugins)
+	assert.True(t, tp.isKilled(p1))
+	assert.True(t, tp.isKilled(p2))
+	// the deferred releases of the callers don't fail after the shutdown
+	assert.NoError(t, m.Release(p1))
+	_, err = m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	assert.ErrorIs(t, err, ErrPluginManagerShutdown)
+}
+
+func TestPluginManagerConcurrentStart(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+	newPlugin := m.newPlugin
+	slow := make(chan struct{})
+	m.newPlugin = func(key, dir string) (*Plugin, error) {
+		if key == "kusionstack/slow@v0.1.0" {
+			<-slow
+		}
+		return newPlugin(key, dir)
+	}
+
+	type result struct {
+		p   *Plugin
+		err error
+	}
+	results := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			p, err := m.Acquire("kusionstack/slow@v0.1.0", "/stack")
+			results <- result{p, err}
+		}()
+	}
+
+	// a slow plugin doesn't block starting or releasing the others
+	assert.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.plugins) == 1
+	}, time.Second, time.Millisecond)
+	p, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	require.NoError(t, m.Release(p))
+
+	close(slow)
+	r1, r2 := <-results, <-results
+	require.NoError(t, r1.err)
+	require.NoError(t, r2.err)
+	assert.Same(t, r1.p, r2.p)
+	assert.Equal(t, 2, tp.startedCount())
+	require.NoError(t, m.Release(r1.p))
+	require.NoError(t, m.Release(r2.p))
+	assert.Error(t, m.Release(r1.p))
+}
+
+func TestPluginManagerShutdownWhileStarting(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+	newPlugin := m.newPlugin
+	starting, slow := make(chan struct{}), make(chan struct{})
+	var started *Plugin
+	m.newPlugin = func(key, dir string) (*Plugin, error) {
+		close(starting)
+		<-slow
+		p, err := newPlugin(key, dir)
+		started = p
+		return p, err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+		errs <- err
+	}()
+	<-starting
+	m.Shutdown()
+	close(slow)
+	assert.ErrorIs(t, <-errs, ErrPluginManagerShutdown)
+	assert.True(t, tp.isKilled(started))
+}
+
+func TestPluginManagerRestartExitedPlugin(t *testing.T) {
+	m, tp := newTestPluginManager(time.Hour)
+
+	p1, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	tp.mu.Lock()
+	tp.exited[p1] = true
+	tp.mu.Unlock()
+
+	p2, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+	assert.NotSame(t, p1, p2)
+	assert.False(t, tp.isKilled(p1))
+
+	// the exited plugin is still managed until its holders release it
+	require.NoError(t, m.Release(p1))
+	assert.True(t, tp.isKilled(p1))
+	assert.Error(t, m.Release(p1))
+	require.NoError(t, m.Release(p2))
+	assert.False(t, tp.isKilled(p2))
+}
+
+// testSignal is a signal which can't be raised, so that the test process isn't signaled again.
+type testSignal struct{}
+
+func (testSignal) String() string { return "test signal" }
+func (testSignal) Signal()        {}
+
+func TestPluginManagerShutdownOnSignals(t *testing.T) {
+	var mu sync.Mutex
+	registered := map[chan<- os.Signal]bool{}
+	notify, stopNotify := signalNotify, signalStop
+	t.Cleanup(func() { signalNotify, signalStop = notify, stopNotify })
+	signalNotify = func(c chan<- os.Signal, sig ...os.Signal) {
+		mu.Lock()
+		defer mu.Unlock()
+		registered[c] = true
+	}
+	signalStop = func(c chan<- os.Signal) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(registered, c)
+	}
+	channels := func() []chan<- os.Signal {
+		mu.Lock()
+		defer mu.Unlock()
+		var chs []chan<- os.Signal
+		for c := range registered {
+			chs = append(chs, c)
+		}
+		return chs
+	}
+
+	// stop unregisters the signals without shutting the manager down
+	m, tp := newTestPluginManager(time.Hour)
+	stop := m.ShutdownOnSignals(testSignal{})
+	assert.Len(t, channels(), 1)
+	stop()
+	stop()
+	assert.Empty(t, channels())
+	p, err := m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	require.NoError(t, err)
+
+	// a received signal kills the live plugins and unregisters the signals
+	stop = m.ShutdownOnSignals(testSignal{})
+	chs := channels()
+	require.Len(t, chs, 1)
+	chs[0] <- testSignal{}
+	assert.Eventually(t, func() bool { return len(channels()) == 0 }, time.Second, time.Millisecond)
+	stop()
+	assert.True(t, tp.isKilled(p))
+	_, err = m.Acquire("kusionstack/mysql@v0.1.0", "/stack")
+	assert.ErrorIs(t, err, ErrPluginManagerShutdown)
+}
@@ -4,18 +4,49 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 
 	yamlv2 "gopkg.in/yaml.v2"
 	"gopkg.in/yaml.v3"
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
 	"kusionstack.io/kusion-module-framework/pkg/log"
 	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+	"kusionstack.io/kusion-module-framework/pkg/module/schema"
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+	"kusionstack.io/kusion-module-framework/pkg/resources/terraform"
 )
 
+// EnvLogRequest enables logging the generator requests received by modules at Debug level, in which
+// the secret store and the sensitive config values are redacted.
+const EnvLogRequest = "KUSION_MODULE_LOG_REQUEST"
+
+// EnvResourceValidation sets how the problems of the generated resources are handled, the value is
+// one of resources.ValidationStrict and resources.ValidationLenient, which is the default.
+const EnvResourceValidation = "KUSION_MODULE_RESOURCE_VALIDATION"
+
 type FrameworkModule interface {
 	Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error)
 }
 
+// Validator is an optional interface a FrameworkModule can implement to reject invalid
+// devConfig or platformConfig before any module in the stack generates resources.
+// Modules that don't implement it are always considered valid.
+type Validator interface {
+	// Validate returns an error if the request is invalid. Multiple problems can be
+	// reported at once by returning an error created with errors.Join.
+	Validate(ctx context.Context, req *GeneratorRequest) error
+}
+
+// TypedConfigs is an optional interface a FrameworkModule can implement to declare the Go
+// structs its devConfig and platformConfig are decoded into. The framework derives the
+// JSON schemas of the configs from these structs and publishes them through GetSchema.
+type TypedConfigs interface {
+	// DevConfigType returns a value of the devConfig struct, nil means no declared type.
+	DevConfigType() any
+	// PlatformConfigType returns a value of the platformConfig struct, nil means no declared type.
+	PlatformConfigType() any
+}
+
 // FrameworkModuleWrapper is a module that implements the proto Module interface.
 // It wraps a dev-centric FrameworkModule into a proto Module
 type FrameworkModuleWrapper struct {
@@ -28,14 +59,34 @@ func (f *FrameworkModuleWrapper) Generate(ctx context.Context, req *proto.Genera
 	if err != nil {
 		return nil, err
 	}
+	if err = contextError(ctx); err != nil {
+		return nil, err
+	}
+	kustomization, err := takeKustomization(request)
+	if err != nil {
+		return nil, err
+	}
 	response, err := f.Module.Generate(ctx, request)
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		// the result is useless to the host after the deadline
+		return nil, ctxErr
+	}
 	if err != nil {
 		return nil, err
 	}
 	if response == nil {
-		log.Info("no resources generated by request:%v", request)
+		log.Infof("no resources generated by module of project %s, stack %s, app %s", request.Project, request.Stack, request.App)
 		return EmptyResponse(), nil
 	}
+	if err = applyKustomization(kustomization, response.Resources); err != nil {
+		return nil, err
+	}
+	if err = inferDependencies(response.Resources, response.ExternalDependencies); err != nil {
+		return nil, err
+	}
+	if err = validateResources(response); err != nil {
+		return nil, err
+	}
 
 	// marshal resources and patcher
 	var resources [][]byte
@@ -56,11 +107,101 @@ func (f *FrameworkModuleWrapper) Generate(ctx context.Context, req *proto.Genera
 	}
 
 	return &proto.GeneratorResponse{
-		Resources: resources,
-		Patcher:   patcher,
+		Resources:   resources,
+		Patcher:     patcher,
+		Diagnostics: response.Diagnostics.ToProto(),
 	}, nil
 }
 
+func (f *FrameworkModuleWrapper) Validate(ctx context.Context, req *proto.GeneratorRequest) (*proto.ValidateResponse, error) {
+	request, err := NewGeneratorRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = takeKustomization(request); err != nil {
+		return &proto.ValidateResponse{Valid: false, Errors: []string{err.Error()}}, nil
+	}
+	validator, ok := f.Module.(Validator)
+	if !ok {
+		return &proto.ValidateResponse{Valid: true}, nil
+	}
+	if err = validator.Validate(ctx, request); err != nil {
+		return &proto.ValidateResponse{Valid: false, Errors: validateErrors(err)}, nil
+	}
+	return &proto.ValidateResponse{Valid: true}, nil
+}
+
+// validateErrors flattens the joined errors returned by a Validator into messages.
+func validateErrors(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+	var msgs []string
+	for _, e := range joined.Unwrap() {
+		msgs = append(msgs, validateErrors(e)...)
+	}
+	return msgs
+}
+
+// validateResources validates the generated resources. The problems fail the generation in the
+// strict mode, otherwise they are appended to the diagnostics of the response as warnings. The
+// incompatible provider versions always fail the generation, since no version can be installed.
+func validateResources(response *GeneratorResponse) error {
+	err := errors.Join(
+		resources.ValidateResources(response.Resources, response.ExternalDependencies...),
+		terraform.ValidateProviderVersions(response.Resources),
+	)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, terraform.ErrIncompatibleVersions) ||
+		resources.ValidationMode(os.Getenv(EnvResourceValidation)) == resources.ValidationStrict {
+		return fmt.Errorf("invalid resources generated: %w", err)
+	}
+	for _, msg := range validateErrors(err) {
+		response.Diagnostics = append(response.Diagnostics, WarningDiagnostic("", msg, ""))
+	}
+	return nil
+}
+
+func (f *FrameworkModuleWrapper) GetSchema(ctx context.Context, req *proto.SchemaRequest) (*proto.SchemaResponse, error) {
+	typed, ok := f.Module.(TypedConfigs)
+	if !ok {
+		return &proto.SchemaResponse{}, nil
+	}
+
+	res := &proto.SchemaResponse{}
+	var err error
+	if dc := typed.DevConfigType(); dc != nil {
+		if res.DevConfig, err = schema.Marshal(dc); err != nil {
+			return nil, fmt.Errorf("derive dev config schema failed. %w", err)
+		}
+	}
+	if pc := typed.PlatformConfigType(); pc != nil {
+		if res.PlatformConfig, err = schema.Marshal(pc); err != nil {
+			return nil, fmt.Errorf("derive platform config schema failed. %w", err)
+		}
+	}
+	return res, nil
+}
+
+func (f *FrameworkModuleWrapper) GetCapabilities(ctx context.Context, req *proto.CapabilitiesRequest) (*proto.CapabilitiesResponse, error) {
+	features := []string{FeatureDiagnostics, FeaturePanicReport}
+	if _, ok := f.Module.(Validator); ok {
+		features = append(features, FeatureValidate)
+	}
+	if _, ok := f.Module.(TypedConfigs); ok {
+		features = append(features, FeatureSchema)
+	}
+	return Capabilities{
+		FrameworkVersion:   FrameworkVersion(),
+		MinProtocolVersion: ProtocolVersionLegacy,
+		MaxProtocolVersion: ProtocolVersion,
+		Features:           features,
+	}.ToProto(), nil
+}
+
 type GeneratorRequest struct {
 	// Project represents the project name
 	Project string `json:"project" yaml:"project"`
@@ -84,11 +225,18 @@ type GeneratorResponse struct {
 	// Resources represents the generated resources
 	Resources []v1.Resource `json:"resources,omitempty" yaml:"resources,omitempty"`
 	Patcher   *v1.Patcher   `json:"patcher,omitempty" yaml:"patcher,omitempty"`
+	// Diagnostics contains the errors, warnings and infos reported by the module. Error diagnostics
+	// are carried to the host along with the resources, which lets a module report several
+	// field-level errors at the same time.
+	Diagnostics Diagnostics `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+	// ExternalDependencies are the IDs of the resources outside the response, e.g. the resources
+	// generated by other modules, which the "$kusion_path" references in the resources may point to.
+	// The referenced resources are added into the dependsOn of the resources automatically, and a
+	// reference to a resource neither in the response nor in ExternalDependencies is an error.
+	ExternalDependencies []string `json:"-" yaml:"-"`
 }
 
 func NewGeneratorRequest(req *proto.GeneratorRequest) (*GeneratorRequest, error) {
-	log.Infof("module proto request received:%s", req.String())
-
 	// validate generator request
 	if req == nil {
 		return nil, errors.New("empty generator request")
@@ -140,14 +288,34 @@ func NewGeneratorRequest(req *proto.GeneratorRequest) (*GeneratorRequest, error)
 		Context:        ctx,
 		SecretStore:    secretStore,
 	}
-	out, err := yaml.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("marshal new generator request failed. %w", err)
+	if os.Getenv(EnvLogRequest) != "" {
+		out, err := yaml.Marshal(redactRequest(result))
+		if err != nil {
+			return nil, fmt.Errorf("marshal new generator request failed. %w", err)
+		}
+		log.Debugf("new generator request:%s", string(out))
 	}
-	log.Infof("new generator request:%s", string(out))
 	return result, nil
 }
 
+// redactRequest returns a view of the request for logging, in which the secret store and the values of
+// sensitive keys in the configs are redacted.
+func redactRequest(req *GeneratorRequest) map[string]any {
+	view := map[string]any{
+		"project":        req.Project,
+		"stack":          req.Stack,
+		"app":            req.App,
+		"workload":       log.Redact(req.Workload),
+		"devConfig":      log.Redact(req.DevConfig),
+		"platformConfig": log.Redact(req.PlatformConfig),
+		"context":        log.Redact(req.Context),
+	}
+	if req.SecretStore.Provider != nil {
+		view["secretStore"] = log.Redacted
+	}
+	return view
+}
+
 // EmptyResponse represents a legal but empty response. Interfaces should return an EmptyResponse instead of nil when the response is empty
 func EmptyResponse() *proto.GeneratorResponse {
 	return &proto.GeneratorResponse{}
@@ -2,6 +2,7 @@ package module
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,6 +10,8 @@ import (
 	"gopkg.in/yaml.v2"
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
 	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+	"kusionstack.io/kusion-module-framework/pkg/resources/terraform"
 )
 
 type mockFrameworkModule struct{}
@@ -49,6 +52,7 @@ func (m *mockFrameworkModule) Generate(ctx context.Context, req *GeneratorReques
 				ID:         "mock-resource",
 				Type:       v1.Kubernetes,
 				Attributes: workload,
+				Extensions: map[string]any{v1.ResourceExtensionGVK: "apps/v1, Kind=Deployment"},
 			},
 		},
 		Patcher: &v1.Patcher{
@@ -128,3 +132,230 @@ func TestGenerateWithEmptyRequest(t *testing.T) {
 	_, err := fmw.Generate(ctx, req)
 	assert.Error(t, err)
 }
+
+type mockValidatorModule struct {
+	mockFrameworkModule
+}
+
+func (m *mockValidatorModule) Validate(ctx context.Context, req *GeneratorRequest) error {
+	var errs []error
+	if _, ok := req.DevConfig["replicas"]; !ok {
+		errs = append(errs, errors.New("devConfig.replicas is required"))
+	}
+	if _, ok := req.PlatformConfig["type"]; !ok {
+		errs = append(errs, errors.New("platformConfig.type is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		module     FrameworkModule
+		req        *proto.GeneratorRequest
+		wantValid  bool
+		wantErrors []string
+	}{
+		{
+			name:      "module without validator is always valid",
+			module:    &mockFrameworkModule{},
+			req:       &proto.GeneratorRequest{Project: "testProject"},
+			wantValid: true,
+		},
+		{
+			name:   "invalid inputs",
+			module: &mockValidatorModule{},
+			req:    &proto.GeneratorRequest{Project: "testProject"},
+			wantErrors: []string{
+				"devConfig.replicas is required",
+				"platformConfig.type is required",
+			},
+		},
+		{
+			name:   "valid inputs",
+			module: &mockValidatorModule{},
+			req: &proto.GeneratorRequest{
+				Project:        "testProject",
+				DevConfig:      []byte(`{"replicas":1}`),
+				PlatformConfig: []byte(`{"type":"aws"}`),
+			},
+			wantValid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmw := &FrameworkModuleWrapper{Module: tt.module}
+			resp, err := fmw.Validate(context.Background(), tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			assert.Equal(t, tt.wantErrors, resp.Errors)
+		})
+	}
+}
+
+func TestValidateWithEmptyRequest(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockValidatorModule{}}
+	_, err := fmw.Validate(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+type mockTypedModule struct {
+	mockFrameworkModule
+}
+
+type mockDevConfig struct {
+	Replicas int `yaml:"replicas" kusion:"required"`
+}
+
+func (m *mockTypedModule) DevConfigType() any {
+	return mockDevConfig{}
+}
+
+func (m *mockTypedModule) PlatformConfigType() any {
+	return nil
+}
+
+func TestGetSchema(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockFrameworkModule{}}
+	resp, err := fmw.GetSchema(context.Background(), &proto.SchemaRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.DevConfig)
+	assert.Empty(t, resp.PlatformConfig)
+
+	fmw = &FrameworkModuleWrapper{Module: &mockTypedModule{}}
+	resp, err = fmw.GetSchema(context.Background(), &proto.SchemaRequest{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["replicas"],
+		"properties": {"replicas": {"type": "integer"}}
+	}`, string(resp.DevConfig))
+	assert.Empty(t, resp.PlatformConfig)
+}
+
+type mockDiagnosticsModule struct{}
+
+func (m *mockDiagnosticsModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	return &GeneratorResponse{
+		Resources: []v1.Resource{{
+			ID:         "mock-resource",
+			Type:       v1.Kubernetes,
+			Extensions: map[string]any{v1.ResourceExtensionGVK: "apps/v1, Kind=Deployment"},
+		}},
+		Diagnostics: Diagnostics{
+			WarningDiagnostic("devConfig.size", "size is deprecated", "use instanceType instead"),
+			ErrorDiagnostic("devConfig.replicas", "replicas must be positive", ""),
+		},
+	}, nil
+}
+
+func TestGenerateWithDiagnostics(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockDiagnosticsModule{}}
+	resp, err := fmw.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	require.NoError(t, err)
+	assert.Len(t, resp.Resources, 1)
+	assert.Equal(t, Diagnostics{
+		WarningDiagnostic("devConfig.size", "size is deprecated", "use instanceType instead"),
+		ErrorDiagnostic("devConfig.replicas", "replicas must be positive", ""),
+	}, DiagnosticsFromProto(resp.Diagnostics))
+}
+
+func TestGetCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   FrameworkModule
+		features []string
+	}{
+		{
+			name:     "basic module",
+			module:   &mockFrameworkModule{},
+			features: []string{FeatureDiagnostics, FeaturePanicReport},
+		},
+		{
+			name:     "module with validator",
+			module:   &mockValidatorModule{},
+			features: []string{FeatureDiagnostics, FeaturePanicReport, FeatureValidate},
+		},
+		{
+			name:     "module with typed configs",
+			module:   &mockTypedModule{},
+			features: []string{FeatureDiagnostics, FeaturePanicReport, FeatureSchema},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmw := &FrameworkModuleWrapper{Module: tt.module}
+			resp, err := fmw.GetCapabilities(context.Background(), &proto.CapabilitiesRequest{})
+			require.NoError(t, err)
+			c := CapabilitiesFromProto(resp)
+			assert.Equal(t, ProtocolVersionLegacy, c.MinProtocolVersion)
+			assert.Equal(t, ProtocolVersion, c.MaxProtocolVersion)
+			assert.Equal(t, tt.features, c.Features)
+		})
+	}
+}
+
+func TestRedactRequest(t *testing.T) {
+	req := &GeneratorRequest{
+		Project:        "p",
+		PlatformConfig: v1.GenericConfig{"databaseName": "db", "databasePassword": "p@ss"},
+		SecretStore:    v1.SecretStore{Provider: &v1.ProviderSpec{}},
+	}
+	view := redactRequest(req)
+	assert.Equal(t, "p", view["project"])
+	assert.Equal(t, map[string]any{"databaseName": "db", "databasePassword": "******"}, view["platformConfig"])
+	assert.Equal(t, "******", view["secretStore"])
+}
+
+type mockInvalidResourcesModule struct{}
+
+func (m *mockInvalidResourcesModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	return &GeneratorResponse{
+		Resources: []v1.Resource{{ID: "mock-resource", Type: v1.Kubernetes, DependsOn: []string{"missing"}}},
+	}, nil
+}
+
+func TestGenerateWithInvalidResources(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockInvalidResourcesModule{}}
+
+	resp, err := fmw.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	require.NoError(t, err)
+	assert.Equal(t, Diagnostics{
+		WarningDiagnostic("", "resource mock-resource: dependsOn missing doesn't exist", ""),
+		WarningDiagnostic("", "resource mock-resource: missing GVK extension", ""),
+	}, DiagnosticsFromProto(resp.Diagnostics))
+
+	t.Setenv(EnvResourceValidation, string(resources.ValidationStrict))
+	_, err = fmw.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	assert.ErrorContains(t, err, "invalid resources generated")
+}
+
+type mockIncompatibleProvidersModule struct{}
+
+func (m *mockIncompatibleProvidersModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	resource := func(id, provider string) v1.Resource {
+		return v1.Resource{ID: id, Type: v1.Terraform, Extensions: map[string]any{
+			resources.ExtensionProvider:     provider,
+			resources.ExtensionResourceType: "aws_s3_bucket",
+		}}
+	}
+	return &GeneratorResponse{
+		Resources: []v1.Resource{
+			resource("hashicorp:aws:aws_s3_bucket:a", "hashicorp/aws/~> 5.0"),
+			resource("hashicorp:aws:aws_s3_bucket:b", "hashicorp/aws/4.67.0"),
+		},
+	}, nil
+}
+
+func TestGenerateWithIncompatibleProviderVersions(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockIncompatibleProvidersModule{}}
+
+	// the incompatible versions fail the generation in both modes
+	for _, mode := range []resources.ValidationMode{resources.ValidationLenient, resources.ValidationStrict} {
+		t.Setenv(EnvResourceValidation, string(mode))
+		_, err := fmw.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+		assert.ErrorIs(t, err, terraform.ErrIncompatibleVersions, mode)
+		assert.ErrorContains(t, err, `resource hashicorp:aws:aws_s3_bucket:b: provider hashicorp/aws version "4.67.0" is incompatible with "~> 5.0" required by resource hashicorp:aws:aws_s3_bucket:a`, mode)
+	}
+}
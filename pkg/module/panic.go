@@ -0,0 +1,78 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"kusionstack.io/kusion-module-framework/pkg/log"
+)
+
+// panicMessagePrefix is the prefix of the gRPC status message of a PanicError.
+const panicMessagePrefix = "module panicked: "
+
+// PanicError is returned when a module panics while serving a request. It carries the panic
+// value and the goroutine stack of the module process to the host.
+type PanicError struct {
+	// Value is the formatted value passed to panic
+	Value string
+	// Stack is the goroutine stack trace captured when the panic was recovered
+	Stack string
+}
+
+// NewPanicError creates a PanicError with the recovered value and the stack of the current goroutine.
+func NewPanicError(v any) *PanicError {
+	return &PanicError{
+		Value: fmt.Sprint(v),
+		Stack: string(debug.Stack()),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return panicMessagePrefix + e.Value
+}
+
+// GRPCStatus converts the PanicError into a gRPC status, the stack is attached as a DebugInfo detail.
+func (e *PanicError) GRPCStatus() *status.Status {
+	st := status.New(codes.Internal, e.Error())
+	withDetails, err := st.WithDetails(&errdetails.DebugInfo{
+		StackEntries: strings.Split(strings.TrimSpace(e.Stack), "\n"),
+		Detail:       e.Value,
+	})
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// panicErrorFromStatus converts the gRPC error returned by a module back into a PanicError.
+// Errors which don't come from a panic are returned unchanged.
+func panicErrorFromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Internal || !strings.HasPrefix(st.Message(), panicMessagePrefix) {
+		return err
+	}
+	pe := &PanicError{Value: strings.TrimPrefix(st.Message(), panicMessagePrefix)}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.DebugInfo); ok {
+			pe.Value = info.Detail
+			pe.Stack = strings.Join(info.StackEntries, "\n")
+		}
+	}
+	return pe
+}
+
+// recoverPanic recovers from a panic of the module and sets err to the status of a PanicError.
+// It must be called directly by defer.
+func recoverPanic(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		pe := NewPanicError(r)
+		log.GetModuleLogger(ctx).Error("module panicked", "method", method, "panic", pe.Value, "stack", pe.Stack)
+		*err = pe.GRPCStatus().Err()
+	}
+}
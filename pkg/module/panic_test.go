@@ -0,0 +1,49 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+	"kusionstack.io/kusion-module-framework/pkg/util/kfile"
+)
+
+type mockPanicModule struct{}
+
+func (m *mockPanicModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	panic("nil map")
+}
+
+func TestGRPCServerRecoverPanic(t *testing.T) {
+	t.Setenv(kfile.EnvKusionHome, t.TempDir())
+	s := &GRPCServer{Impl: &FrameworkModuleWrapper{Module: &mockPanicModule{}}}
+
+	res, err := s.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	assert.Nil(t, res)
+	require.Error(t, err)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "module panicked: nil map", st.Message())
+	assert.Len(t, st.Details(), 1)
+
+	var pe *PanicError
+	require.True(t, errors.As(panicErrorFromStatus(err), &pe))
+	assert.Equal(t, "nil map", pe.Value)
+	assert.Contains(t, pe.Stack, "mockPanicModule")
+}
+
+func TestPanicErrorFromStatus(t *testing.T) {
+	err := status.Error(codes.Internal, "connection reset")
+	assert.Equal(t, err, panicErrorFromStatus(err))
+
+	err = errors.New("plain error")
+	assert.Equal(t, err, panicErrorFromStatus(err))
+}
@@ -1,6 +1,7 @@
 package module
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,11 +9,13 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 
 	"kusionstack.io/kusion-module-framework/pkg/log"
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
 	"kusionstack.io/kusion-module-framework/pkg/util/kfile"
 )
 
@@ -24,9 +27,10 @@ const (
 
 var mu sync.Mutex
 
-// HandshakeConfig is a common handshake that is shared by plugin and host.
+// HandshakeConfig is a common handshake that is shared by plugin and host. The ProtocolVersion is
+// only used by legacy hosts and plugins, the others negotiate it with VersionedPluginSets.
 var HandshakeConfig = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
+	ProtocolVersion:  ProtocolVersionLegacy,
 	MagicCookieKey:   "MODULE_PLUGIN",
 	MagicCookieValue: "ON",
 }
@@ -40,11 +44,19 @@ type Plugin struct {
 	// key represents the module key, it consists of two parts: namespace/moduleName@version. e.g. "kusionstack/mysql@v0.1.0"
 	key    string
 	client *plugin.Client
-	// Module represents the real module impl
+	// Module represents the real module impl, whose Generate is bounded by the GenerateTimeout.
 	Module Module
 	// dir represents the working directory of the plugin binary, which will be typically set as the stack path.
 	dir        string
 	ModuleName string
+	// Capabilities represents the features supported by the module binary, the host should
+	// only use the features supported by it.
+	Capabilities Capabilities
+	// GenerateTimeout is the timeout of each Generate call, zero means no timeout.
+	GenerateTimeout time.Duration
+	// KillGracePeriod is how long to wait for the module to stop after the timeout before killing
+	// the plugin process, zero means DefaultKillGracePeriod.
+	KillGracePeriod time.Duration
 }
 
 func NewPlugin(key, dir string) (*Plugin, error) {
@@ -59,26 +71,46 @@ func NewPlugin(key, dir string) (*Plugin, error) {
 	return p, nil
 }
 
-func (p *Plugin) initModule() error {
-	key := p.key
+// parseModuleKey parses the module key in the format of org/moduleName@version.
+func parseModuleKey(key string) (namespace, name, version string, err error) {
 	split := strings.Split(key, "@")
 	msg := "init module failed. Invalid plugin module key: %s. " +
 		"The correct format for a key should be as follows: org/moduleName@version. e.g. kusionstack/mysql@v0.1.0"
 	if len(split) != 2 {
-		return fmt.Errorf(msg, key)
+		return "", "", "", fmt.Errorf(msg, key)
 	}
 	prefix := strings.Split(split[0], "/")
 	if len(prefix) != 2 {
-		return fmt.Errorf(msg, key)
+		return "", "", "", fmt.Errorf(msg, key)
 	}
+	return prefix[0], prefix[1], split[1], nil
+}
 
-	// build the plugin client
-	pluginPath, err := buildPluginPath(prefix[0], prefix[1], split[1])
+func (p *Plugin) initModule() error {
+	key := p.key
+	namespace, name, version, err := parseModuleKey(key)
 	if err != nil {
 		return err
 	}
-	pluginName := prefix[0] + "-" + prefix[1]
+	pluginName := namespace + "-" + name
 	p.ModuleName = pluginName
+
+	// call the module registered in the same process without launching the plugin binary
+	if impl, ok := lookupInProcess(key); ok {
+		p.Module = p.withTimeout(NewInProcessModule(impl))
+		res, err := p.Module.GetCapabilities(context.Background(), &proto.CapabilitiesRequest{})
+		if err != nil {
+			return fmt.Errorf("get capabilities of in-process kusion module: %s failed. %w", key, err)
+		}
+		p.Capabilities = CapabilitiesFromProto(res)
+		return nil
+	}
+
+	// build the plugin client
+	pluginPath, err := buildPluginPath(namespace, name, version)
+	if err != nil {
+		return err
+	}
 	client, err := NewPluginClient(pluginPath, pluginName, p.dir)
 	if err != nil {
 		return err
@@ -94,11 +126,26 @@ func (p *Plugin) initModule() error {
 	if err != nil {
 		return err
 	}
-	p.Module = raw.(Module)
+	p.Module = p.withTimeout(raw.(Module))
+
+	// module binaries speaking the legacy protocol don't serve GetCapabilities
+	p.Capabilities = LegacyCapabilities()
+	if client.NegotiatedVersion() >= ProtocolVersion {
+		res, err := p.Module.GetCapabilities(context.Background(), &proto.CapabilitiesRequest{})
+		if err != nil {
+			return fmt.Errorf("get capabilities of kusion module plugin: %s failed. %w", key, err)
+		}
+		p.Capabilities = CapabilitiesFromProto(res)
+	}
 
 	return nil
 }
 
+// Supports returns whether the module binary supports the feature.
+func (p *Plugin) Supports(feature string) bool {
+	return p.Capabilities.Supports(feature)
+}
+
 func buildPluginPath(namespace, resourceType, version string) (string, error) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -127,6 +174,12 @@ func buildPluginPath(namespace, resourceType, version string) (string, error) {
 }
 
 func NewPluginClient(modulePluginPath, moduleName, workingDir string) (*plugin.Client, error) {
+	// verify the module binary is not tampered with or swapped out
+	secureConfig, err := NewSecureConfig(modulePluginPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// create the plugin log file
 	var logFilePath string
 	dir, err := kfile.KusionDataFolder()
@@ -157,19 +210,24 @@ func NewPluginClient(modulePluginPath, moduleName, workingDir string) (*plugin.C
 
 	// We're a host! Start by launching the plugin process.Need to defer kill
 	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig: HandshakeConfig,
-		Plugins:         PluginMap,
-		Cmd:             cmd,
+		HandshakeConfig:  HandshakeConfig,
+		VersionedPlugins: VersionedPluginSets(nil),
+		Cmd:              cmd,
 		AllowedProtocols: []plugin.Protocol{
 			plugin.ProtocolGRPC,
 		},
-		Logger: logger,
+		Logger:       logger,
+		SecureConfig: secureConfig,
 	})
 	return client, nil
 }
 
 func (p *Plugin) KillPluginClient() error {
 	if p.client == nil {
+		if _, ok := lookupInProcess(p.key); ok {
+			// in-process modules have no plugin process to kill
+			return nil
+		}
 		return fmt.Errorf("plugin: %s client is nil", p.key)
 	}
 	p.client.Kill()
@@ -0,0 +1,243 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Draft is the JSON schema dialect of the generated schemas.
+	Draft = "https://json-schema.org/draft/2020-12/schema"
+
+	// TagKusion is the struct tag holding field options, e.g. `kusion:"required"`.
+	TagKusion = "kusion"
+	// TagDefault is the struct tag holding the default value of a field, e.g. `default:"1"`.
+	TagDefault = "default"
+	// TagDescription is the struct tag holding the description of a field.
+	TagDescription = "description"
+	// OptionRequired marks a field as required in the kusion tag.
+	OptionRequired = "required"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema is a subset of the JSON schema which is enough to describe module configs.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// openAPIV3OneOfTyper is implemented by Kubernetes types like resource.Quantity and
+// intstr.IntOrString which can be unmarshalled from several JSON types.
+type openAPIV3OneOfTyper interface {
+	OpenAPIV3OneOfTypes() []string
+}
+
+// openAPISchemaTyper is implemented by types which describe their own OpenAPI type.
+type openAPISchemaTyper interface {
+	OpenAPISchemaType() []string
+	OpenAPISchemaFormat() string
+}
+
+// For derives the JSON schema of v from its Go type and struct tags. Property names are
+// taken from the yaml tag, then the json tag, then the lowercased field name as yaml does.
+func For(v any) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("can not derive schema from nil")
+	}
+	s, err := forType(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = Draft
+	return s, nil
+}
+
+// Marshal derives the JSON schema of v and returns it in JSON format.
+func Marshal(v any) ([]byte, error) {
+	s, err := For(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+func forType(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	zero := reflect.Zero(t).Interface()
+	if typer, ok := zero.(openAPIV3OneOfTyper); ok {
+		s := &Schema{}
+		for _, typ := range typer.OpenAPIV3OneOfTypes() {
+			s.OneOf = append(s.OneOf, &Schema{Type: typ})
+		}
+		return s, nil
+	}
+	if typer, ok := zero.(openAPISchemaTyper); ok && len(typer.OpenAPISchemaType()) == 1 {
+		return &Schema{Type: typer.OpenAPISchemaType()[0], Format: typer.OpenAPISchemaFormat()}, nil
+	}
+	if t == durationType {
+		return &Schema{Type: "string", Format: "duration"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := forType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s, only string keys are allowed", t.Key())
+		}
+		values, err := forType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			// recursive types are described as plain objects
+			return &Schema{Type: "object"}, nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		if err := addProperties(s, t, visiting); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// addProperties adds the exported fields of the struct type t into s, inlined fields are flattened.
+func addProperties(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, inline, skip := FieldName(f)
+		if skip {
+			continue
+		}
+		if inline {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addProperties(s, ft, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				continue
+			}
+		}
+
+		prop, err := forType(f.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		prop.Description = f.Tag.Get(TagDescription)
+		if def, ok := f.Tag.Lookup(TagDefault); ok {
+			prop.Default = defaultValue(def, prop.Type)
+		}
+		if HasOption(f, OptionRequired) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return nil
+}
+
+// FieldName returns the config key of the struct field. Inline is true if the field is an
+// anonymous struct without a name or is tagged with ",inline", skip is true if the field is
+// unexported or tagged with "-".
+func FieldName(f reflect.StructField) (name string, inline, skip bool) {
+	if !f.IsExported() && !f.Anonymous {
+		return "", false, true
+	}
+	tag, ok := f.Tag.Lookup("yaml")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if name == "" {
+		if f.Anonymous {
+			return "", true, false
+		}
+		name = strings.ToLower(f.Name)
+	}
+	return name, inline, false
+}
+
+// HasOption returns whether the struct field is tagged with the given kusion option.
+func HasOption(f reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(f.Tag.Get(TagKusion), ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultValue converts the default tag into the JSON type of the schema.
+func defaultValue(def, typ string) any {
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(def, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
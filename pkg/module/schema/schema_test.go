@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type base struct {
+	Type string `yaml:"type" kusion:"required" description:"cloud provider type"`
+}
+
+type node struct {
+	Name     string  `yaml:"name"`
+	Children []*node `yaml:"children"`
+}
+
+type config struct {
+	base     `yaml:",inline"`
+	Replicas int               `yaml:"replicas" default:"1"`
+	Enabled  bool              `json:"enabled" default:"true"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	CPU      resource.Quantity `yaml:"cpu"`
+	Labels   map[string]string `yaml:"labels,omitempty"`
+	Ports    []int             `yaml:"ports"`
+	Tree     *node             `yaml:"tree"`
+	Version  string
+	Ignored  string `yaml:"-"`
+	internal string
+}
+
+func TestFor(t *testing.T) {
+	s, err := For(config{})
+	require.NoError(t, err)
+
+	expected := &Schema{
+		Schema:   Draft,
+		Type:     "object",
+		Required: []string{"type"},
+		Properties: map[string]*Schema{
+			"type":     {Type: "string", Description: "cloud provider type"},
+			"replicas": {Type: "integer", Default: int64(1)},
+			"enabled":  {Type: "boolean", Default: true},
+			"timeout":  {Type: "string", Format: "duration"},
+			"cpu":      {OneOf: []*Schema{{Type: "string"}, {Type: "number"}}},
+			"labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"ports":    {Type: "array", Items: &Schema{Type: "integer"}},
+			"tree": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":     {Type: "string"},
+					"children": {Type: "array", Items: &Schema{Type: "object"}},
+				},
+			},
+			"version": {Type: "string"},
+		},
+	}
+	assert.Equal(t, expected, s)
+}
+
+func TestForUnsupportedTypes(t *testing.T) {
+	_, err := For(nil)
+	assert.Error(t, err)
+
+	_, err = For(struct {
+		M map[int]string `yaml:"m"`
+	}{})
+	assert.ErrorContains(t, err, "field M")
+
+	_, err = For(struct {
+		C chan int `yaml:"c"`
+	}{})
+	assert.Error(t, err)
+}
+
+func TestMarshal(t *testing.T) {
+	out, err := Marshal(&base{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["type"],
+		"properties": {"type": {"type": "string", "description": "cloud provider type"}}
+	}`, string(out))
+}
@@ -0,0 +1,111 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"kusionstack.io/kusion-module-framework/pkg/log"
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+const (
+	// DeadlineMetadataKey is the gRPC metadata key of the deadline the module must finish Generate by.
+	DeadlineMetadataKey = "kusion_module_deadline"
+	// DefaultKillGracePeriod is the default duration to wait for a module to stop after its deadline
+	// before the plugin process is killed.
+	DefaultKillGracePeriod = 5 * time.Second
+)
+
+// ErrModuleTimeout is returned when a module doesn't finish Generate within the timeout.
+var ErrModuleTimeout = errors.New("module generate timed out")
+
+// timeoutModule is the Module of a Plugin, which bounds Generate by the GenerateTimeout of the
+// plugin, so calling p.Module.Generate directly is bounded as Plugin.Generate is.
+type timeoutModule struct {
+	Module
+	plugin *Plugin
+}
+
+func (m *timeoutModule) Generate(ctx context.Context, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error) {
+	return m.plugin.generate(ctx, m.Module, req)
+}
+
+// withTimeout wraps the module to bound its Generate by the GenerateTimeout of the plugin.
+func (p *Plugin) withTimeout(m Module) Module {
+	if tm, ok := m.(*timeoutModule); ok && tm.plugin == p {
+		return m
+	}
+	return &timeoutModule{Module: m, plugin: p}
+}
+
+// Generate calls Generate of the module with the GenerateTimeout of the plugin. The deadline is
+// propagated into the plugin process, and the plugin process is killed if the module ignores
+// the cancellation for longer than the KillGracePeriod.
+func (p *Plugin) Generate(ctx context.Context, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error) {
+	return p.withTimeout(p.Module).Generate(ctx, req)
+}
+
+func (p *Plugin) generate(ctx context.Context, m Module, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error) {
+	if p.GenerateTimeout <= 0 {
+		return m.Generate(ctx, req)
+	}
+
+	deadline := time.Now().Add(p.GenerateTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	grace := p.KillGracePeriod
+	if grace <= 0 {
+		grace = DefaultKillGracePeriod
+	}
+
+	// keep the call alive for the grace period after the deadline, so that a module that stops
+	// in time can be told apart from a module that ignores the cancellation.
+	callCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline.Add(grace))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	defer stop()
+	callCtx = metadata.AppendToOutgoingContext(callCtx, DeadlineMetadataKey, deadline.Format(time.RFC3339Nano))
+
+	res, err := m.Generate(callCtx, req)
+	if status.Code(err) != codes.DeadlineExceeded {
+		return res, err
+	}
+	if callCtx.Err() != nil {
+		log.Warnf("module %s ignored the cancellation for %s, killing the plugin process", p.key, grace)
+		p.kill()
+	}
+	return nil, fmt.Errorf("%w: module %s didn't finish in %s", ErrModuleTimeout, p.key, p.GenerateTimeout)
+}
+
+// withModuleDeadline returns a context with the deadline propagated by the host.
+func withModuleDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	values := metadata.ValueFromIncomingContext(ctx, DeadlineMetadataKey)
+	if len(values) == 0 {
+		return context.WithCancel(ctx)
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		log.Warnf("ignore invalid module deadline %s: %v", values[0], err)
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// contextError converts the error of a done context into a gRPC status error.
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+// sleepModule sleeps before generating, it stops early on cancellation if cooperative is true.
+type sleepModule struct {
+	sleep       time.Duration
+	cooperative bool
+}
+
+func (m *sleepModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	if !m.cooperative {
+		time.Sleep(m.sleep)
+		return &GeneratorResponse{}, nil
+	}
+	select {
+	case <-time.After(m.sleep):
+		return &GeneratorResponse{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newTimeoutPlugin(m FrameworkModule, timeout time.Duration) *Plugin {
+	return &Plugin{
+		key:             "kusionstack/mysql@v0.1.0",
+		Module:          NewInProcessModule(m),
+		GenerateTimeout: timeout,
+		KillGracePeriod: 50 * time.Millisecond,
+	}
+}
+
+func TestPluginGenerateTimeout(t *testing.T) {
+	req := &proto.GeneratorRequest{Project: "testProject"}
+
+	p := newTimeoutPlugin(&sleepModule{sleep: time.Millisecond, cooperative: true}, time.Second)
+	_, err := p.Generate(context.Background(), req)
+	require.NoError(t, err)
+
+	p = newTimeoutPlugin(&sleepModule{sleep: time.Second, cooperative: true}, 20*time.Millisecond)
+	_, err = p.Generate(context.Background(), req)
+	assert.ErrorIs(t, err, ErrModuleTimeout)
+
+	p = newTimeoutPlugin(&sleepModule{sleep: time.Second}, 20*time.Millisecond)
+	_, err = p.Generate(context.Background(), req)
+	assert.ErrorIs(t, err, ErrModuleTimeout)
+
+	// the deadline of the parent context is respected
+	p = newTimeoutPlugin(&sleepModule{sleep: time.Second, cooperative: true}, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.Generate(ctx, req)
+	assert.ErrorIs(t, err, ErrModuleTimeout)
+}
+
+func TestPluginModuleGenerateTimeout(t *testing.T) {
+	key := "kusionstack/sleep@v0.1.0"
+	require.NoError(t, RegisterInProcess(key, &sleepModule{sleep: time.Second, cooperative: true}))
+	t.Cleanup(func() { UnregisterInProcess(key) })
+
+	p, err := NewPlugin(key, "")
+	require.NoError(t, err)
+	p.GenerateTimeout = 20 * time.Millisecond
+	p.KillGracePeriod = 50 * time.Millisecond
+
+	// the module of the plugin is bounded as well
+	_, err = p.Module.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	assert.ErrorIs(t, err, ErrModuleTimeout)
+	_, err = p.Generate(context.Background(), &proto.GeneratorRequest{Project: "testProject"})
+	assert.ErrorIs(t, err, ErrModuleTimeout)
+}
+
+func TestPluginGenerateCanceled(t *testing.T) {
+	p := newTimeoutPlugin(&sleepModule{sleep: time.Second, cooperative: true}, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := p.Generate(ctx, &proto.GeneratorRequest{Project: "testProject"})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.False(t, errors.Is(err, ErrModuleTimeout))
+}
+
+func TestWithModuleDeadline(t *testing.T) {
+	ctx, cancel := withModuleDeadline(context.Background())
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	deadline := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	md := metadata.Pairs(DeadlineMetadataKey, deadline.Format(time.RFC3339Nano))
+	ctx, cancel = withModuleDeadline(metadata.NewIncomingContext(context.Background(), md))
+	defer cancel()
+	got, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, deadline.Equal(got))
+
+	md = metadata.Pairs(DeadlineMetadataKey, "tomorrow")
+	ctx, cancel = withModuleDeadline(metadata.NewIncomingContext(context.Background(), md))
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestGenerateWithDoneContext(t *testing.T) {
+	fmw := &FrameworkModuleWrapper{Module: &mockFrameworkModule{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := fmw.Generate(ctx, &proto.GeneratorRequest{Project: "testProject"})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
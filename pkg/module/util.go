@@ -12,10 +12,12 @@ import (
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
 
 	"kusionstack.io/kusion-module-framework/pkg/log"
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+	"kusionstack.io/kusion-module-framework/pkg/resources/kubernetes"
 )
 
 const (
-	ImportIDKey = "kusionstack.io/import-id"
+	ImportIDKey = resources.ExtensionImportID
 )
 
 var ErrEmptyTFProviderVersion = errors.New("empty terraform provider version")
@@ -25,8 +27,7 @@ var defaultTFHost = "registry.terraform.io"
 func WrapK8sResourceToKusionResource(id string, resource runtime.Object) (*v1.Resource, error) {
 	gvk := resource.GetObjectKind().GroupVersionKind().String()
 
-	// fixme: this function converts int to int64 by default
-	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+	unstructured, err := kubernetes.ToUnstructured(resource)
 	if err != nil {
 		return nil, err
 	}
@@ -290,7 +291,8 @@ func MergeMaps(maps ...map[string]string) map[string]string {
 }
 
 // KusionPathDependency returns the implicit resource dependency path based on
-// the resource id and name with the "$kusion_path" prefix.
+// the resource id and name with the "$kusion_path" prefix. The FrameworkModuleWrapper adds the
+// referenced resource into the dependsOn of the resource using the path automatically.
 func KusionPathDependency(id, name string) string {
 	return "$kusion_path." + id + "." + name
 }
@@ -300,7 +302,7 @@ func PatchResource[T any](resources map[string][]*v1.Resource, gvk string, patch
 	var obj T
 	for _, r := range resources[gvk] {
 		// convert unstructured to typed object
-		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(r.Attributes, &obj); err != nil {
+		if err := kubernetes.FromUnstructured(r.Attributes, &obj); err != nil {
 			return err
 		}
 
@@ -309,7 +311,7 @@ func PatchResource[T any](resources map[string][]*v1.Resource, gvk string, patch
 		}
 
 		// convert typed object to unstructured
-		updated, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&obj)
+		updated, err := kubernetes.ToUnstructured(&obj)
 		if err != nil {
 			return err
 		}
@@ -317,3 +319,17 @@ func PatchResource[T any](resources map[string][]*v1.Resource, gvk string, patch
 	}
 	return nil
 }
+
+// ManifestsToKusionResources converts the multi-document YAML or JSON Kubernetes manifests shipped by
+// the module, e.g. CRDs and RBAC, into Kusion resources. The namespaced objects without a namespace
+// are put in the namespace named after the application of the request, unless opts.Namespace is set.
+func ManifestsToKusionResources(request *GeneratorRequest, manifests []byte, opts *kubernetes.ManifestOptions) (v1.Resources, error) {
+	o := kubernetes.ManifestOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Namespace == "" && request != nil {
+		o.Namespace = request.App
+	}
+	return kubernetes.ManifestsToKusionResources(manifests, &o)
+}
@@ -8,6 +8,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources/kubernetes"
 )
 
 func TestForeachOrdered(t *testing.T) {
@@ -132,3 +134,27 @@ func TestPatchResource(t *testing.T) {
 		resources["/v1, Kind=Namespace"][0].Attributes["metadata"].(map[string]interface{})["labels"].(map[string]interface{}),
 	)
 }
+
+func TestManifestsToKusionResources(t *testing.T) {
+	manifests := []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: other
+  namespace: kube-system
+`)
+	request := &GeneratorRequest{Project: "foo", Stack: "dev", App: "bar"}
+
+	resources, err := ManifestsToKusionResources(request, manifests, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:ServiceAccount:bar:operator", resources[0].ID)
+	assert.Equal(t, "v1:ServiceAccount:kube-system:other", resources[1].ID)
+
+	resources, err = ManifestsToKusionResources(request, manifests, &kubernetes.ManifestOptions{Namespace: "ops"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1:ServiceAccount:ops:operator", resources[0].ID)
+}
@@ -0,0 +1,207 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-plugin"
+
+	"kusionstack.io/kusion-module-framework/pkg/log"
+	"kusionstack.io/kusion-module-framework/pkg/util/kfile"
+)
+
+const (
+	// EnvModuleVerify sets how strictly module binaries are verified before launch, the value is
+	// one of VerifyChecksum, which is the default, VerifyStrict and VerifySkip.
+	EnvModuleVerify = "KUSION_MODULE_VERIFY"
+	// EnvModuleTrustStore overrides the directory of the trusted public keys.
+	EnvModuleTrustStore = "KUSION_MODULE_TRUST_STORE"
+
+	// VerifyChecksum verifies the checksum manifest if it exists, otherwise the binary is launched
+	// with a warning. If any trusted key is configured, both the manifest and its signature are
+	// required, and the signature is verified.
+	VerifyChecksum = "checksum"
+	// VerifyStrict requires both the checksum manifest and its signature signed by a trusted key.
+	VerifyStrict = "strict"
+	// VerifySkip opts out of verifying module binaries.
+	VerifySkip = "skip"
+
+	// ChecksumFile is the name of the checksum manifest shipped next to the module binary, each
+	// line of which is in the sha256sum format: "<hex sha256>  <file name>".
+	ChecksumFile = "checksums.txt"
+	// SignatureFile is the name of the base64 encoded detached signature of the checksum manifest.
+	SignatureFile = ChecksumFile + ".sig"
+	// TrustStoreDir is the directory under the kusion data folder holding the trusted public keys
+	// in PEM format, both ed25519 and ECDSA (cosign) keys are supported.
+	TrustStoreDir = "trusted_keys"
+)
+
+var (
+	ErrChecksumNotFound   = errors.New("module binary checksum not found")
+	ErrSignatureNotFound  = errors.New("module checksum signature not found")
+	ErrSignatureUntrusted = errors.New("module checksum signature is not signed by any trusted key")
+)
+
+// NewSecureConfig verifies the checksum manifest of the module binary and its signature, and returns
+// the go-plugin SecureConfig which checks the binary against the manifest right before launching it.
+// A nil SecureConfig is returned if the verification is skipped by VerifySkip, or the manifest doesn't
+// exist in the default mode.
+func NewSecureConfig(binaryPath string) (*plugin.SecureConfig, error) {
+	mode := os.Getenv(EnvModuleVerify)
+	switch mode {
+	case "", VerifyChecksum, VerifyStrict:
+	case VerifySkip:
+		log.Warnf("skip verifying module binary %s, %s is %s", binaryPath, EnvModuleVerify, VerifySkip)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid %s %q, must be one of %s, %s and %s", EnvModuleVerify, mode, VerifyChecksum, VerifyStrict, VerifySkip)
+	}
+	dir := filepath.Dir(binaryPath)
+
+	keys, err := loadTrustedKeys()
+	if err != nil {
+		return nil, err
+	}
+	// the trusted keys opt into verifying the signatures, which sign the manifest
+	signed := mode == VerifyStrict || len(keys) != 0
+
+	manifest, err := os.ReadFile(filepath.Join(dir, ChecksumFile))
+	if os.IsNotExist(err) {
+		if signed {
+			return nil, fmt.Errorf("%w: %s doesn't exist", ErrChecksumNotFound, filepath.Join(dir, ChecksumFile))
+		}
+		log.Warnf("module binary %s is not verified, %s doesn't exist", binaryPath, filepath.Join(dir, ChecksumFile))
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read module checksum manifest failed: %w", err)
+	}
+
+	if signed {
+		signature, err := os.ReadFile(filepath.Join(dir, SignatureFile))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s doesn't exist", ErrSignatureNotFound, filepath.Join(dir, SignatureFile))
+		} else if err != nil {
+			return nil, fmt.Errorf("read module checksum signature failed: %w", err)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("verify module %s failed: %w: no trusted key configured", binaryPath, ErrSignatureUntrusted)
+		}
+		if err = verifySignature(manifest, signature, keys); err != nil {
+			return nil, fmt.Errorf("verify module %s failed: %w", binaryPath, err)
+		}
+	}
+
+	checksum, err := findChecksum(manifest, filepath.Base(binaryPath))
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.SecureConfig{
+		Checksum: checksum,
+		Hash:     sha256.New(),
+	}, nil
+}
+
+// findChecksum finds the sha256 checksum of the file name in the checksum manifest.
+func findChecksum(manifest []byte, name string) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode files with a leading "*"
+		if strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		checksum, err := hex.DecodeString(fields[0])
+		if err != nil || len(checksum) != sha256.Size {
+			return nil, fmt.Errorf("invalid sha256 checksum of %s: %s", name, fields[0])
+		}
+		return checksum, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: no entry for %s in %s", ErrChecksumNotFound, name, ChecksumFile)
+}
+
+// verifySignature verifies the base64 encoded signature of the manifest is signed by one of the keys.
+func verifySignature(manifest, signature []byte, keys []crypto.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("decode module checksum signature failed: %w", err)
+	}
+	digest := sha256.Sum256(manifest)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, manifest, sig) {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, digest[:], sig) {
+				return nil
+			}
+		}
+	}
+	return ErrSignatureUntrusted
+}
+
+// trustStorePath returns the directory of the trusted public keys.
+func trustStorePath() (string, error) {
+	if env, found := os.LookupEnv(EnvModuleTrustStore); found {
+		return env, nil
+	}
+	dir, err := kfile.KusionDataFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, TrustStoreDir), nil
+}
+
+// loadTrustedKeys loads all PEM encoded public keys in the trust store, which may not exist.
+func loadTrustedKeys() ([]crypto.PublicKey, error) {
+	dir, err := trustStorePath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read module trust store failed: %w", err)
+	}
+
+	var keys []crypto.PublicKey
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "PUBLIC KEY" {
+			log.Warnf("skip %s in module trust store, not a PEM encoded public key", entry.Name())
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse trusted key %s failed: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,186 @@
+package module
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBinaryName = "kusion-module-mysql_v0.1.0"
+
+// setupModuleBinary writes a fake module binary and its checksum manifest into a temp dir.
+func setupModuleBinary(t *testing.T) (binaryPath string, manifest []byte) {
+	dir := t.TempDir()
+	binaryPath = filepath.Join(dir, testBinaryName)
+	content := []byte("#!/bin/sh\necho mysql\n")
+	require.NoError(t, os.WriteFile(binaryPath, content, 0o755))
+
+	sum := sha256.Sum256(content)
+	manifest = []byte(hex.EncodeToString(sum[:]) + "  " + testBinaryName + "\n" +
+		"0000000000000000000000000000000000000000000000000000000000000000  kusion-module-redis_v0.1.0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ChecksumFile), manifest, 0o644))
+	// no trusted key is configured by default
+	t.Setenv(EnvModuleTrustStore, t.TempDir())
+	return binaryPath, manifest
+}
+
+// trustKey writes the public key into a temp trust store.
+func trustKey(t *testing.T, pub any) {
+	dir := t.TempDir()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	out := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release.pub"), out, 0o644))
+	t.Setenv(EnvModuleTrustStore, dir)
+}
+
+func writeSignature(t *testing.T, binaryPath string, sig []byte) {
+	path := filepath.Join(filepath.Dir(binaryPath), SignatureFile)
+	require.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(sig)), 0o644))
+}
+
+func TestNewSecureConfigWithoutManifest(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), testBinaryName)
+
+	t.Setenv(EnvModuleTrustStore, t.TempDir())
+
+	// the binaries without manifests are launched with warnings in the default mode
+	for _, mode := range []string{"", VerifyChecksum, VerifySkip} {
+		t.Setenv(EnvModuleVerify, mode)
+		sc, err := NewSecureConfig(binaryPath)
+		assert.NoError(t, err, mode)
+		assert.Nil(t, sc, mode)
+	}
+
+	t.Setenv(EnvModuleVerify, VerifyStrict)
+	_, err := NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrChecksumNotFound)
+
+	// the manifest is required if any trusted key is configured
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	trustKey(t, pub)
+	t.Setenv(EnvModuleVerify, "")
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrChecksumNotFound)
+
+	t.Setenv(EnvModuleVerify, "none")
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorContains(t, err, `invalid KUSION_MODULE_VERIFY "none"`)
+}
+
+func TestNewSecureConfigChecksum(t *testing.T) {
+	binaryPath, _ := setupModuleBinary(t)
+	t.Setenv(EnvModuleVerify, VerifyChecksum)
+
+	sc, err := NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	ok, err := sc.Check(binaryPath)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a tampered binary must not pass the check
+	require.NoError(t, os.WriteFile(binaryPath, []byte("#!/bin/sh\necho evil\n"), 0o755))
+	ok, err = sc.Check(binaryPath)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// a swapped binary has no entry in the manifest
+	_, err = NewSecureConfig(filepath.Join(filepath.Dir(binaryPath), "kusion-module-postgres_v0.1.0"))
+	assert.ErrorIs(t, err, ErrChecksumNotFound)
+
+	// signature is required in strict mode
+	t.Setenv(EnvModuleVerify, VerifyStrict)
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrSignatureNotFound)
+}
+
+func TestNewSecureConfigRequiresSignatureWithTrustedKeys(t *testing.T) {
+	binaryPath, manifest := setupModuleBinary(t)
+	t.Setenv(EnvModuleVerify, "")
+
+	// the default mode accepts an unsigned manifest only if no key is trusted
+	sc, err := NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	assert.NotNil(t, sc)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	trustKey(t, pub)
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrSignatureNotFound)
+
+	writeSignature(t, binaryPath, ed25519.Sign(priv, manifest))
+	sc, err = NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	assert.NotNil(t, sc)
+
+	// the signature is ignored without trusted keys in the default mode
+	_, untrusted, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeSignature(t, binaryPath, ed25519.Sign(untrusted, manifest))
+	t.Setenv(EnvModuleTrustStore, t.TempDir())
+	sc, err = NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	assert.NotNil(t, sc)
+
+	// but it can't be verified without trusted keys in strict mode
+	t.Setenv(EnvModuleVerify, VerifyStrict)
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrSignatureUntrusted)
+}
+
+func TestNewSecureConfigEd25519Signature(t *testing.T) {
+	binaryPath, manifest := setupModuleBinary(t)
+	t.Setenv(EnvModuleVerify, VerifyStrict)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	trustKey(t, pub)
+	writeSignature(t, binaryPath, ed25519.Sign(priv, manifest))
+
+	sc, err := NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	assert.NotNil(t, sc)
+
+	// the manifest signed by an untrusted key
+	_, untrusted, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeSignature(t, binaryPath, ed25519.Sign(untrusted, manifest))
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrSignatureUntrusted)
+}
+
+func TestNewSecureConfigECDSASignature(t *testing.T) {
+	binaryPath, manifest := setupModuleBinary(t)
+	t.Setenv(EnvModuleVerify, VerifyStrict)
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	trustKey(t, &priv.PublicKey)
+	digest := sha256.Sum256(manifest)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	require.NoError(t, err)
+	writeSignature(t, binaryPath, sig)
+
+	sc, err := NewSecureConfig(binaryPath)
+	require.NoError(t, err)
+	assert.NotNil(t, sc)
+
+	// a tampered manifest doesn't match the signature
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(binaryPath), ChecksumFile), append(manifest, '\n'), 0o644))
+	_, err = NewSecureConfig(binaryPath)
+	assert.ErrorIs(t, err, ErrSignatureUntrusted)
+}
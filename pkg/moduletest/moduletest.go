@@ -0,0 +1,199 @@
+// Package moduletest helps module authors test a FrameworkModule the way the host runs it: requests
+// are built from YAML fixtures, go through the FrameworkModuleWrapper and the proto marshaling, and
+// the generated resources and patcher are compared with golden YAML files.
+//
+// Run "go test ./... -update" to rewrite the golden files with the current output. The update flag is
+// registered by the package unless a flag of the same name is already defined, in which case that
+// flag is honored, and "KUSION_MODULE_UPDATE_GOLDEN=true go test ./..." works as a fallback.
+package moduletest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/proto"
+)
+
+// EnvUpdateGolden rewrites the golden files with the current output if it is true.
+const EnvUpdateGolden = "KUSION_MODULE_UPDATE_GOLDEN"
+
+// UpdateFlag is the name of the flag rewriting the golden files with the current output.
+const UpdateFlag = "update"
+
+// Update rewrites the golden files with the current output if it is true, which is set by the
+// UpdateFlag registered by the package.
+var Update bool
+
+func init() {
+	registerUpdateFlag(flag.CommandLine)
+}
+
+// registerUpdateFlag registers the UpdateFlag unless it is already defined.
+func registerUpdateFlag(fs *flag.FlagSet) {
+	if fs.Lookup(UpdateFlag) == nil {
+		fs.BoolVar(&Update, UpdateFlag, false, "update the golden files")
+	}
+}
+
+// Fixture is the YAML fixture of a GeneratorRequest.
+type Fixture struct {
+	Project        string          `yaml:"project"`
+	Stack          string          `yaml:"stack"`
+	App            string          `yaml:"app"`
+	Workload       map[string]any  `yaml:"workload,omitempty"`
+	DevConfig      map[string]any  `yaml:"devConfig,omitempty"`
+	PlatformConfig map[string]any  `yaml:"platformConfig,omitempty"`
+	Context        map[string]any  `yaml:"context,omitempty"`
+	SecretStore    *v1.SecretStore `yaml:"secretStore,omitempty"`
+}
+
+// Result is the decoded output of a module.
+type Result struct {
+	Resources   v1.Resources       `yaml:"resources,omitempty"`
+	Patcher     *v1.Patcher        `yaml:"patcher,omitempty"`
+	Diagnostics module.Diagnostics `yaml:"diagnostics,omitempty"`
+}
+
+// LoadFixture reads the YAML fixture at path.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture failed: %w", err)
+	}
+	f := &Fixture{}
+	if err = yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("unmarshal fixture %s failed: %w", path, err)
+	}
+	return f, nil
+}
+
+// NewRequest builds the proto GeneratorRequest of the fixture. As the host does, the workload is
+// marshaled with yaml.v2 while the others are marshaled with yaml.v3.
+func NewRequest(f *Fixture) (*proto.GeneratorRequest, error) {
+	req := &proto.GeneratorRequest{
+		Project: f.Project,
+		Stack:   f.Stack,
+		App:     f.App,
+	}
+
+	var err error
+	if f.Workload != nil {
+		if req.Workload, err = yamlv2.Marshal(f.Workload); err != nil {
+			return nil, fmt.Errorf("marshal workload failed: %w", err)
+		}
+	}
+	if f.DevConfig != nil {
+		if req.DevConfig, err = yaml.Marshal(f.DevConfig); err != nil {
+			return nil, fmt.Errorf("marshal dev config failed: %w", err)
+		}
+	}
+	if f.PlatformConfig != nil {
+		if req.PlatformConfig, err = yaml.Marshal(f.PlatformConfig); err != nil {
+			return nil, fmt.Errorf("marshal platform config failed: %w", err)
+		}
+	}
+	if f.Context != nil {
+		if req.Context, err = yaml.Marshal(f.Context); err != nil {
+			return nil, fmt.Errorf("marshal context failed: %w", err)
+		}
+	}
+	if f.SecretStore != nil {
+		if req.SecretStore, err = yaml.Marshal(f.SecretStore); err != nil {
+			return nil, fmt.Errorf("marshal secret store failed: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// Run runs the module with the request through the FrameworkModuleWrapper and the proto marshaling,
+// and decodes the response.
+func Run(ctx context.Context, m module.FrameworkModule, req *proto.GeneratorRequest) (*Result, error) {
+	res, err := module.NewInProcessModule(m).Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeResponse(res)
+}
+
+// DecodeResponse decodes the resources, patcher and diagnostics in the proto GeneratorResponse.
+func DecodeResponse(res *proto.GeneratorResponse) (*Result, error) {
+	result := &Result{Diagnostics: module.DiagnosticsFromProto(res.Diagnostics)}
+	for _, out := range res.Resources {
+		var r v1.Resource
+		if err := yaml.Unmarshal(out, &r); err != nil {
+			return nil, fmt.Errorf("unmarshal resource failed: %w", err)
+		}
+		result.Resources = append(result.Resources, r)
+	}
+	if len(res.Patcher) != 0 {
+		result.Patcher = &v1.Patcher{}
+		if err := yaml.Unmarshal(res.Patcher, result.Patcher); err != nil {
+			return nil, fmt.Errorf("unmarshal patcher failed: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// AssertGolden compares the result with the golden YAML file, or rewrites the golden file if the
+// golden files are being updated by the UpdateFlag or EnvUpdateGolden.
+func AssertGolden(t testing.TB, golden string, result *Result) {
+	t.Helper()
+	actual, err := yaml.Marshal(result)
+	require.NoError(t, err)
+
+	if updating() {
+		require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+		require.NoError(t, os.WriteFile(golden, actual, 0o644))
+		return
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err, "golden file doesn't exist, run the test with -%s to create it", UpdateFlag)
+	assert.Equal(t, string(expected), string(actual), "output doesn't match golden file %s", golden)
+}
+
+// Golden runs the module with the request built from the fixture file, and compares the output
+// with the golden file.
+func Golden(t testing.TB, m module.FrameworkModule, fixture, golden string) {
+	t.Helper()
+	f, err := LoadFixture(fixture)
+	require.NoError(t, err)
+	req, err := NewRequest(f)
+	require.NoError(t, err)
+	result, err := Run(context.Background(), m, req)
+	require.NoError(t, err)
+	AssertGolden(t, golden, result)
+}
+
+func updating() bool {
+	return flagUpdating(flag.CommandLine) || envUpdating()
+}
+
+// flagUpdating returns whether the UpdateFlag is set, which may be defined by others.
+func flagUpdating(fs *flag.FlagSet) bool {
+	if Update {
+		return true
+	}
+	f := fs.Lookup(UpdateFlag)
+	if f == nil {
+		return false
+	}
+	update, _ := strconv.ParseBool(f.Value.String())
+	return update
+}
+
+func envUpdating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(EnvUpdateGolden))
+	return update
+}
@@ -0,0 +1,112 @@
+package moduletest
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+type pvcModule struct{}
+
+func (m *pvcModule) Generate(ctx context.Context, req *module.GeneratorRequest) (*module.GeneratorResponse, error) {
+	pvc := v1.Resource{
+		ID:   "v1:PersistentVolumeClaim:" + req.App + ":" + req.App + "-data",
+		Type: v1.Kubernetes,
+		Attributes: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]any{
+				"name":      req.App + "-data",
+				"namespace": req.App,
+			},
+			"spec": map[string]any{
+				"storageClassName": req.PlatformConfig["storageClass"],
+				"resources": map[string]any{
+					"requests": map[string]any{"storage": req.DevConfig["size"]},
+				},
+			},
+		},
+		Extensions: map[string]any{v1.ResourceExtensionGVK: "/v1, Kind=PersistentVolumeClaim"},
+	}
+	return &module.GeneratorResponse{
+		Resources: []v1.Resource{pvc},
+		Patcher: &v1.Patcher{
+			Labels: map[string]string{"storage": "enabled"},
+		},
+		Diagnostics: module.Diagnostics{
+			module.WarningDiagnostic("devConfig.size", "size will be rounded up to Gi", ""),
+		},
+	}, nil
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, &pvcModule{}, filepath.Join("testdata", "request.yaml"), filepath.Join("testdata", "golden.yaml"))
+}
+
+func TestNewRequest(t *testing.T) {
+	f, err := LoadFixture(filepath.Join("testdata", "request.yaml"))
+	require.NoError(t, err)
+	req, err := NewRequest(f)
+	require.NoError(t, err)
+
+	// the request must be accepted by the framework the same way as the one from the host
+	r, err := module.NewGeneratorRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", r.Project)
+	assert.Equal(t, "service.Service", r.Workload["_type"])
+	assert.Equal(t, "10Gi", r.DevConfig["size"])
+	assert.Equal(t, "standard", r.PlatformConfig["storageClass"])
+	assert.Equal(t, "us-east-1", r.Context["region"])
+	assert.Equal(t, "us-east-1", r.SecretStore.Provider.AWS.Region)
+}
+
+func TestLoadFixtureNotExist(t *testing.T) {
+	_, err := LoadFixture(filepath.Join("testdata", "not-exist.yaml"))
+	assert.Error(t, err)
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "golden.yaml")
+	fixture := filepath.Join("testdata", "request.yaml")
+
+	t.Setenv(EnvUpdateGolden, "true")
+	Golden(t, &pvcModule{}, fixture, golden)
+	expected, err := os.ReadFile(filepath.Join("testdata", "golden.yaml"))
+	require.NoError(t, err)
+	actual, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+
+	// the update flag is registered by the package
+	t.Setenv(EnvUpdateGolden, "")
+	require.NoError(t, os.Remove(golden))
+	require.NotNil(t, flag.Lookup(UpdateFlag))
+	require.NoError(t, flag.Set(UpdateFlag, "true"))
+	t.Cleanup(func() { _ = flag.Set(UpdateFlag, "false") })
+	Golden(t, &pvcModule{}, fixture, golden)
+	assert.FileExists(t, golden)
+}
+
+func TestUpdateFlagAlreadyDefined(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	update := fs.Bool(UpdateFlag, false, "defined by the module test")
+	assert.NotPanics(t, func() { registerUpdateFlag(fs) })
+
+	// the flag defined by others is honored
+	assert.False(t, flagUpdating(fs))
+	require.NoError(t, fs.Parse([]string{"-" + UpdateFlag}))
+	assert.True(t, *update)
+	assert.True(t, flagUpdating(fs))
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	registerUpdateFlag(fs)
+	assert.NotNil(t, fs.Lookup(UpdateFlag))
+}
@@ -0,0 +1,196 @@
+package patcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources/kubernetes"
+)
+
+// podTemplatePaths are the paths of the pod templates of the supported workload kinds.
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// Apply applies the patcher to the rendered Kubernetes workload in place:
+//   - the labels and annotations are set on the workload
+//   - the pod labels and annotations are set on the pod template
+//   - the environment variables are set on all containers and init containers, replacing the
+//     variables with the same names
+//   - the JSON patcher of the workload ID is applied at last
+//
+// The supported workload kinds are Deployment, StatefulSet, DaemonSet, ReplicaSet, Job and CronJob.
+func Apply(workload *v1.Resource, patcher *v1.Patcher) error {
+	if workload == nil || patcher == nil {
+		return nil
+	}
+	if workload.Type != v1.Kubernetes {
+		return fmt.Errorf("workload %s is not a Kubernetes resource", workload.ID)
+	}
+	if workload.Attributes == nil {
+		return fmt.Errorf("workload %s has no attributes", workload.ID)
+	}
+
+	setStrings(workload.Attributes, patcher.Labels, "metadata", "labels")
+	setStrings(workload.Attributes, patcher.Annotations, "metadata", "annotations")
+
+	if len(patcher.PodLabels) != 0 || len(patcher.PodAnnotations) != 0 || len(patcher.Environments) != 0 {
+		kind, _ := workload.Attributes["kind"].(string)
+		path, ok := podTemplatePaths[kind]
+		if !ok {
+			return fmt.Errorf("unsupported workload kind %q of %s", kind, workload.ID)
+		}
+		template, err := nestedMap(workload.Attributes, path...)
+		if err != nil {
+			return fmt.Errorf("invalid pod template of %s: %w", workload.ID, err)
+		}
+		setStrings(template, patcher.PodLabels, "metadata", "labels")
+		setStrings(template, patcher.PodAnnotations, "metadata", "annotations")
+		if err = setEnvironments(template, patcher.Environments); err != nil {
+			return fmt.Errorf("patch environments of %s failed: %w", workload.ID, err)
+		}
+	}
+
+	if jp, ok := patcher.JSONPatchers[workload.ID]; ok {
+		patched, err := ApplyJSONPatcher(workload.Attributes, jp)
+		if err != nil {
+			return fmt.Errorf("apply %s to %s failed: %w", jp.Type, workload.ID, err)
+		}
+		workload.Attributes = patched
+	}
+	return nil
+}
+
+// ApplyJSONPatcher applies the JSON patch or the JSON merge patch to the attributes, and returns the
+// patched attributes in which the integers are int.
+func ApplyJSONPatcher(attributes map[string]any, jp v1.JSONPatcher) (map[string]any, error) {
+	doc, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	switch jp.Type {
+	case v1.MergePatch:
+		doc, err = jsonpatch.MergePatch(doc, jp.Payload)
+	case v1.JSONPatch:
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(jp.Payload); err == nil {
+			doc, err = patch.Apply(doc)
+		}
+	default:
+		err = fmt.Errorf("unknown patch type %q", jp.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, decoding it with yaml keeps the integers as int like the Kusion engine
+	var patched map[string]any
+	if err = yaml.Unmarshal(doc, &patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// setEnvironments sets the environment variables on all containers of the pod template.
+func setEnvironments(template map[string]any, envs []corev1.EnvVar) error {
+	if len(envs) == 0 {
+		return nil
+	}
+	for _, field := range []string{"containers", "initContainers"} {
+		containers, _, err := unstructured.NestedFieldNoCopy(template, "spec", field)
+		if err != nil || containers == nil {
+			continue
+		}
+		items, ok := containers.([]any)
+		if !ok {
+			return fmt.Errorf("%s is not a list", field)
+		}
+		for i, item := range items {
+			container, ok := item.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s[%d] is not a map", field, i)
+			}
+			merged, err := mergeEnvironments(container["env"], envs)
+			if err != nil {
+				return fmt.Errorf("%s[%d]: %w", field, i, err)
+			}
+			container["env"] = merged
+		}
+	}
+	return nil
+}
+
+// mergeEnvironments replaces the variables with the same names and appends the others.
+func mergeEnvironments(existing any, envs []corev1.EnvVar) ([]any, error) {
+	var merged []any
+	if existing != nil {
+		list, ok := existing.([]any)
+		if !ok {
+			return nil, fmt.Errorf("env is not a list")
+		}
+		merged = append(merged, list...)
+	}
+	for _, env := range envs {
+		value, err := kubernetes.ToUnstructured(&env)
+		if err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i, item := range merged {
+			if m, ok := item.(map[string]any); ok && m["name"] == env.Name {
+				merged[i] = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, value)
+		}
+	}
+	return merged, nil
+}
+
+// setStrings sets the entries into the string map at the path, which is created if missing.
+func setStrings(obj map[string]any, entries map[string]string, path ...string) {
+	if len(entries) == 0 {
+		return
+	}
+	m, err := nestedMap(obj, path...)
+	if err != nil {
+		return
+	}
+	for k, v := range entries {
+		m[k] = v
+	}
+}
+
+// nestedMap returns the map at the path, the missing maps are created.
+func nestedMap(obj map[string]any, path ...string) (map[string]any, error) {
+	current := obj
+	for i, field := range path {
+		next, ok := current[field]
+		if !ok || next == nil {
+			m := map[string]any{}
+			current[field] = m
+			current = m
+			continue
+		}
+		m, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a map", path[:i+1])
+		}
+		current = m
+	}
+	return current, nil
+}
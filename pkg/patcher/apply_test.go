@@ -0,0 +1,172 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func deployment() *v1.Resource {
+	return &v1.Resource{
+		ID:   "apps/v1:Deployment:default:foo",
+		Type: v1.Kubernetes,
+		Attributes: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "foo", "namespace": "default"},
+			"spec": map[string]any{
+				"replicas": 1,
+				"template": map[string]any{
+					"spec": map[string]any{
+						"containers": []any{
+							map[string]any{
+								"name": "main",
+								"env":  []any{map[string]any{"name": "DB_HOST", "value": "localhost"}},
+							},
+							map[string]any{"name": "sidecar"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := NewBuilder().
+		Env("DB_HOST", "mysql").
+		Env("DB_PORT", "3306").
+		Label("app", "foo").
+		Annotation("owner", "infra").
+		PodLabel("tier", "backend").
+		PodAnnotation("prometheus.io/scrape", "true").
+		JSONPatch("apps/v1:Deployment:default:foo", Operation{Op: "replace", Path: "/spec/replicas", Value: 3}).
+		Build()
+	require.NoError(t, err)
+
+	workload := deployment()
+	require.NoError(t, Apply(workload, p))
+	assert.Equal(t, map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":        "foo",
+			"namespace":   "default",
+			"labels":      map[string]any{"app": "foo"},
+			"annotations": map[string]any{"owner": "infra"},
+		},
+		"spec": map[string]any{
+			"replicas": 3,
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"labels":      map[string]any{"tier": "backend"},
+					"annotations": map[string]any{"prometheus.io/scrape": "true"},
+				},
+				"spec": map[string]any{
+					"containers": []any{
+						map[string]any{
+							"name": "main",
+							"env": []any{
+								map[string]any{"name": "DB_HOST", "value": "mysql"},
+								map[string]any{"name": "DB_PORT", "value": "3306"},
+							},
+						},
+						map[string]any{
+							"name": "sidecar",
+							"env": []any{
+								map[string]any{"name": "DB_HOST", "value": "mysql"},
+								map[string]any{"name": "DB_PORT", "value": "3306"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, workload.Attributes)
+}
+
+func TestApplyCronJob(t *testing.T) {
+	workload := &v1.Resource{
+		ID:   "batch/v1:CronJob:default:foo",
+		Type: v1.Kubernetes,
+		Attributes: map[string]any{
+			"apiVersion": "batch/v1",
+			"kind":       "CronJob",
+			"spec": map[string]any{
+				"jobTemplate": map[string]any{
+					"spec": map[string]any{
+						"template": map[string]any{
+							"spec": map[string]any{"containers": []any{map[string]any{"name": "main"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	p := &v1.Patcher{
+		Environments: []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+		PodLabels:    map[string]string{"a": "b"},
+	}
+	require.NoError(t, Apply(workload, p))
+	template := workload.Attributes["spec"].(map[string]any)["jobTemplate"].(map[string]any)["spec"].(map[string]any)["template"].(map[string]any)
+	assert.Equal(t, map[string]any{"labels": map[string]any{"a": "b"}}, template["metadata"])
+	assert.Equal(t, []any{map[string]any{"name": "main", "env": []any{map[string]any{"name": "FOO", "value": "bar"}}}},
+		template["spec"].(map[string]any)["containers"])
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	p, err := NewBuilder().
+		MergePatch("apps/v1:Deployment:default:foo", map[string]any{"spec": map[string]any{"replicas": 2, "paused": true}}).
+		MergePatch("apps/v1:Deployment:default:bar", map[string]any{"spec": nil}).
+		Build()
+	require.NoError(t, err)
+
+	workload := deployment()
+	require.NoError(t, Apply(workload, p))
+	spec := workload.Attributes["spec"].(map[string]any)
+	assert.Equal(t, 2, spec["replicas"])
+	assert.Equal(t, true, spec["paused"])
+	assert.NotNil(t, spec["template"])
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		workload *v1.Resource
+		patcher  *v1.Patcher
+		err      string
+	}{
+		{
+			name:     "not kubernetes",
+			workload: &v1.Resource{ID: "foo", Type: v1.Terraform, Attributes: map[string]any{}},
+			patcher:  &v1.Patcher{},
+			err:      "workload foo is not a Kubernetes resource",
+		},
+		{
+			name: "unsupported kind",
+			workload: &v1.Resource{
+				ID:         "v1:Service:default:foo",
+				Type:       v1.Kubernetes,
+				Attributes: map[string]any{"kind": "Service"},
+			},
+			patcher: &v1.Patcher{PodLabels: map[string]string{"a": "b"}},
+			err:     `unsupported workload kind "Service" of v1:Service:default:foo`,
+		},
+		{
+			name:     "invalid json patch",
+			workload: deployment(),
+			patcher: &v1.Patcher{JSONPatchers: map[string]v1.JSONPatcher{
+				"apps/v1:Deployment:default:foo": {Type: v1.JSONPatch, Payload: []byte(`[{"op":"remove","path":"/spec/missing"}]`)},
+			}},
+			err: "apply JSONPatch to apps/v1:Deployment:default:foo failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, Apply(tt.workload, tt.patcher), tt.err)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package patcher helps modules build the v1.Patcher returned in the GeneratorResponse, apply it to
+// a rendered workload the way the Kusion engine does, and detect the conflicts between the patchers
+// of several modules.
+package patcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	corev1 "k8s.io/api/core/v1"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// Operation is a JSON patch operation defined in RFC 6902.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value"`
+}
+
+// Builder builds a v1.Patcher fluently, the errors are returned by Build.
+type Builder struct {
+	patcher *v1.Patcher
+	errs    []error
+}
+
+// NewBuilder returns a Builder of an empty patcher.
+func NewBuilder() *Builder {
+	return &Builder{patcher: &v1.Patcher{}}
+}
+
+// Env sets the environment variable of all containers in the workload.
+func (b *Builder) Env(name, value string) *Builder {
+	return b.EnvVar(corev1.EnvVar{Name: name, Value: value})
+}
+
+// EnvVar sets the environment variable of all containers in the workload, which may refer to a
+// Secret or ConfigMap by ValueFrom. A variable with the same name set before is replaced.
+func (b *Builder) EnvVar(env corev1.EnvVar) *Builder {
+	if env.Name == "" {
+		b.errs = append(b.errs, errors.New("empty environment variable name"))
+		return b
+	}
+	for i := range b.patcher.Environments {
+		if b.patcher.Environments[i].Name == env.Name {
+			b.patcher.Environments[i] = env
+			return b
+		}
+	}
+	b.patcher.Environments = append(b.patcher.Environments, env)
+	return b
+}
+
+// Label sets the label of the workload.
+func (b *Builder) Label(key, value string) *Builder {
+	b.patcher.Labels = set(b.patcher.Labels, key, value)
+	return b
+}
+
+// Annotation sets the annotation of the workload.
+func (b *Builder) Annotation(key, value string) *Builder {
+	b.patcher.Annotations = set(b.patcher.Annotations, key, value)
+	return b
+}
+
+// PodLabel sets the label of the pods of the workload.
+func (b *Builder) PodLabel(key, value string) *Builder {
+	b.patcher.PodLabels = set(b.patcher.PodLabels, key, value)
+	return b
+}
+
+// PodAnnotation sets the annotation of the pods of the workload.
+func (b *Builder) PodAnnotation(key, value string) *Builder {
+	b.patcher.PodAnnotations = set(b.patcher.PodAnnotations, key, value)
+	return b
+}
+
+// JSONPatch adds the RFC 6902 JSON patch operations of the resource.
+func (b *Builder) JSONPatch(resourceID string, ops ...Operation) *Builder {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("marshal JSON patch of %s failed: %w", resourceID, err))
+		return b
+	}
+	return b.addJSONPatcher(resourceID, v1.JSONPatch, payload)
+}
+
+// MergePatch adds the RFC 7386 JSON merge patch of the resource, which is combined with the merge
+// patches of the resource added before.
+func (b *Builder) MergePatch(resourceID string, patch any) *Builder {
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("marshal merge patch of %s failed: %w", resourceID, err))
+		return b
+	}
+	return b.addJSONPatcher(resourceID, v1.MergePatch, payload)
+}
+
+func (b *Builder) addJSONPatcher(resourceID string, typ v1.PatchType, payload []byte) *Builder {
+	if resourceID == "" {
+		b.errs = append(b.errs, errors.New("empty resource id of JSON patcher"))
+		return b
+	}
+	if existing, ok := b.patcher.JSONPatchers[resourceID]; ok {
+		var err error
+		switch {
+		case existing.Type != typ:
+			err = fmt.Errorf("resource %s already has a %s", resourceID, existing.Type)
+		case typ == v1.JSONPatch:
+			payload, err = concatJSONPatches(existing.Payload, payload)
+		default:
+			payload, err = jsonpatch.MergeMergePatches(existing.Payload, payload)
+		}
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("add %s of %s failed: %w", typ, resourceID, err))
+			return b
+		}
+	}
+	if b.patcher.JSONPatchers == nil {
+		b.patcher.JSONPatchers = map[string]v1.JSONPatcher{}
+	}
+	b.patcher.JSONPatchers[resourceID] = v1.JSONPatcher{Type: typ, Payload: payload}
+	return b
+}
+
+// concatJSONPatches returns the JSON patch with the operations of both patches.
+func concatJSONPatches(first, second []byte) ([]byte, error) {
+	var ops, more []json.RawMessage
+	if err := json.Unmarshal(first, &ops); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(second, &more); err != nil {
+		return nil, err
+	}
+	return json.Marshal(append(ops, more...))
+}
+
+// Build returns the patcher, or the errors of building it.
+func (b *Builder) Build() (*v1.Patcher, error) {
+	if err := errors.Join(b.errs...); err != nil {
+		return nil, err
+	}
+	return b.patcher, nil
+}
+
+func set(m map[string]string, key, value string) map[string]string {
+	if m == nil {
+		m = map[string]string{}
+	}
+	m[key] = value
+	return m
+}
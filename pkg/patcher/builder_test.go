@@ -0,0 +1,71 @@
+package patcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestBuilder(t *testing.T) {
+	secretRef := &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+			Key:                  "password",
+		},
+	}
+	p, err := NewBuilder().
+		Env("DB_HOST", "localhost").
+		EnvVar(corev1.EnvVar{Name: "DB_PASSWORD", ValueFrom: secretRef}).
+		Env("DB_HOST", "mysql").
+		Label("app", "foo").
+		Annotation("owner", "infra").
+		PodLabel("tier", "backend").
+		PodAnnotation("prometheus.io/scrape", "true").
+		JSONPatch("apps/v1:Deployment:default:foo", Operation{Op: "add", Path: "/spec/replicas", Value: 2}).
+		JSONPatch("apps/v1:Deployment:default:foo", Operation{Op: "remove", Path: "/spec/paused"}).
+		MergePatch("v1:Service:default:foo", map[string]any{"spec": map[string]any{"type": "NodePort"}}).
+		MergePatch("v1:Service:default:foo", map[string]any{"metadata": map[string]any{"labels": map[string]any{"a": "b"}}}).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "DB_HOST", Value: "mysql"},
+		{Name: "DB_PASSWORD", ValueFrom: secretRef},
+	}, p.Environments)
+	assert.Equal(t, map[string]string{"app": "foo"}, p.Labels)
+	assert.Equal(t, map[string]string{"owner": "infra"}, p.Annotations)
+	assert.Equal(t, map[string]string{"tier": "backend"}, p.PodLabels)
+	assert.Equal(t, map[string]string{"prometheus.io/scrape": "true"}, p.PodAnnotations)
+
+	jp := p.JSONPatchers["apps/v1:Deployment:default:foo"]
+	assert.Equal(t, v1.JSONPatch, jp.Type)
+	assert.JSONEq(t, `[{"op":"add","path":"/spec/replicas","value":2},{"op":"remove","path":"/spec/paused","value":null}]`, string(jp.Payload))
+
+	mp := p.JSONPatchers["v1:Service:default:foo"]
+	assert.Equal(t, v1.MergePatch, mp.Type)
+	var merged map[string]any
+	require.NoError(t, json.Unmarshal(mp.Payload, &merged))
+	assert.Equal(t, map[string]any{
+		"spec":     map[string]any{"type": "NodePort"},
+		"metadata": map[string]any{"labels": map[string]any{"a": "b"}},
+	}, merged)
+}
+
+func TestBuilderErrors(t *testing.T) {
+	_, err := NewBuilder().
+		Env("", "foo").
+		JSONPatch("", Operation{Op: "add", Path: "/a", Value: 1}).
+		JSONPatch("v1:Service:default:foo", Operation{Op: "add", Path: "/a", Value: 1}).
+		MergePatch("v1:Service:default:foo", map[string]any{"a": 2}).
+		MergePatch("v1:Service:default:bar", func() {}).
+		Build()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "empty environment variable name")
+	assert.ErrorContains(t, err, "empty resource id of JSON patcher")
+	assert.ErrorContains(t, err, "resource v1:Service:default:foo already has a JSONPatch")
+	assert.ErrorContains(t, err, "marshal merge patch of v1:Service:default:bar failed")
+}
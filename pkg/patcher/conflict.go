@@ -0,0 +1,130 @@
+package patcher
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// Fields of the patcher reported in the conflicts.
+const (
+	FieldEnvironments   = "environments"
+	FieldLabels         = "labels"
+	FieldAnnotations    = "annotations"
+	FieldPodLabels      = "podLabels"
+	FieldPodAnnotations = "podAnnotations"
+	FieldJSONPatchers   = "jsonPatchers"
+)
+
+// Conflict is a key of a patcher field set differently by the patchers of several modules, which
+// makes the patched workload depend on the order the patchers are applied.
+type Conflict struct {
+	// Field is the patcher field, e.g. FieldLabels.
+	Field string
+	// Key is the environment variable name, the label or annotation key, or the resource ID of the
+	// JSON patchers.
+	Key string
+	// Sources are the sorted names of the modules setting the key.
+	Sources []string
+	// Values are the values set by the sources, in the order of the sources.
+	Values []string
+}
+
+func (c Conflict) String() string {
+	settings := make([]string, len(c.Sources))
+	for i := range c.Sources {
+		settings[i] = fmt.Sprintf("%s=%q", c.Sources[i], c.Values[i])
+	}
+	return fmt.Sprintf("%s %q is set differently by modules: %s", c.Field, c.Key, strings.Join(settings, ", "))
+}
+
+// DetectConflicts detects the conflicts between the patchers keyed by the module names. A key set to
+// the same value by several modules is not a conflict, while JSON patchers of the same resource from
+// several modules are always conflicts. The conflicts are sorted by the field and the key.
+func DetectConflicts(patchers map[string]*v1.Patcher) []Conflict {
+	// field -> key -> source -> value
+	settings := map[string]map[string]map[string]string{}
+	add := func(field, key, source, value string) {
+		if settings[field] == nil {
+			settings[field] = map[string]map[string]string{}
+		}
+		if settings[field][key] == nil {
+			settings[field][key] = map[string]string{}
+		}
+		settings[field][key][source] = value
+	}
+	addStrings := func(field, source string, m map[string]string) {
+		for k, v := range m {
+			add(field, k, source, v)
+		}
+	}
+
+	for source, p := range patchers {
+		if p == nil {
+			continue
+		}
+		for _, env := range p.Environments {
+			add(FieldEnvironments, env.Name, source, envValue(env))
+		}
+		addStrings(FieldLabels, source, p.Labels)
+		addStrings(FieldAnnotations, source, p.Annotations)
+		addStrings(FieldPodLabels, source, p.PodLabels)
+		addStrings(FieldPodAnnotations, source, p.PodAnnotations)
+		for id, jp := range p.JSONPatchers {
+			add(FieldJSONPatchers, id, source, fmt.Sprintf("%s %s", jp.Type, bytes.TrimSpace(jp.Payload)))
+		}
+	}
+
+	var conflicts []Conflict
+	for field, keys := range settings {
+		for key, values := range keys {
+			if len(values) < 2 || (field != FieldJSONPatchers && sameValues(values)) {
+				continue
+			}
+			c := Conflict{Field: field, Key: key}
+			for source := range values {
+				c.Sources = append(c.Sources, source)
+			}
+			sort.Strings(c.Sources)
+			for _, source := range c.Sources {
+				c.Values = append(c.Values, values[source])
+			}
+			conflicts = append(conflicts, c)
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Field != conflicts[j].Field {
+			return conflicts[i].Field < conflicts[j].Field
+		}
+		return conflicts[i].Key < conflicts[j].Key
+	})
+	return conflicts
+}
+
+// envValue returns the value of the environment variable for comparison, a reference by ValueFrom
+// is compared by its string form.
+func envValue(env corev1.EnvVar) string {
+	if env.ValueFrom != nil {
+		return env.ValueFrom.String()
+	}
+	return env.Value
+}
+
+func sameValues(values map[string]string) bool {
+	first := true
+	var value string
+	for _, v := range values {
+		if first {
+			value, first = v, false
+			continue
+		}
+		if v != value {
+			return false
+		}
+	}
+	return true
+}
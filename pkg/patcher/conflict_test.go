@@ -0,0 +1,47 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	patchers := map[string]*v1.Patcher{
+		"mysql": {
+			Environments: []corev1.EnvVar{{Name: "DB_HOST", Value: "mysql"}, {Name: "REGION", Value: "us"}},
+			Labels:       map[string]string{"team": "infra", "app": "foo"},
+			JSONPatchers: map[string]v1.JSONPatcher{
+				"apps/v1:Deployment:default:foo": {Type: v1.MergePatch, Payload: []byte(`{"a":1}`)},
+			},
+		},
+		"postgres": {
+			Environments: []corev1.EnvVar{{Name: "DB_HOST", Value: "postgres"}, {Name: "REGION", Value: "us"}},
+			Labels:       map[string]string{"team": "data", "app": "foo"},
+			JSONPatchers: map[string]v1.JSONPatcher{
+				"apps/v1:Deployment:default:foo": {Type: v1.MergePatch, Payload: []byte(`{"a":1}`)},
+			},
+		},
+		"monitoring": {
+			PodAnnotations: map[string]string{"prometheus.io/scrape": "true"},
+		},
+		"empty": nil,
+	}
+
+	conflicts := DetectConflicts(patchers)
+	assert.Equal(t, []Conflict{
+		{Field: FieldEnvironments, Key: "DB_HOST", Sources: []string{"mysql", "postgres"}, Values: []string{"mysql", "postgres"}},
+		{
+			Field:   FieldJSONPatchers,
+			Key:     "apps/v1:Deployment:default:foo",
+			Sources: []string{"mysql", "postgres"},
+			Values:  []string{`MergePatch {"a":1}`, `MergePatch {"a":1}`},
+		},
+		{Field: FieldLabels, Key: "team", Sources: []string{"mysql", "postgres"}, Values: []string{"infra", "data"}},
+	}, conflicts)
+	assert.Equal(t, `labels "team" is set differently by modules: mysql="infra", postgres="data"`, conflicts[2].String())
+
+	assert.Empty(t, DetectConflicts(map[string]*v1.Patcher{"monitoring": patchers["monitoring"]}))
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1alpha1 "k8s.io/api/admissionregistration/v1alpha1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apiserverinternalv1alpha1 "k8s.io/api/apiserverinternal/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authenticationv1alpha1 "k8s.io/api/authentication/v1alpha1"
+	authenticationv1beta1 "k8s.io/api/authentication/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	authorizationv1beta1 "k8s.io/api/authorization/v1beta1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	certificatesv1alpha1 "k8s.io/api/certificates/v1alpha1"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	coordinationv1alpha1 "k8s.io/api/coordination/v1alpha1"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	eventsv1 "k8s.io/api/events/v1"
+	eventsv1beta1 "k8s.io/api/events/v1beta1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	flowcontrolv1beta1 "k8s.io/api/flowcontrol/v1beta1"
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
+	flowcontrolv1beta3 "k8s.io/api/flowcontrol/v1beta3"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1alpha1 "k8s.io/api/networking/v1alpha1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	nodev1 "k8s.io/api/node/v1"
+	nodev1alpha1 "k8s.io/api/node/v1alpha1"
+	nodev1beta1 "k8s.io/api/node/v1beta1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	rbacv1alpha1 "k8s.io/api/rbac/v1alpha1"
+	rbacv1beta1 "k8s.io/api/rbac/v1beta1"
+	resourcev1alpha3 "k8s.io/api/resource/v1alpha3"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	schedulingv1alpha1 "k8s.io/api/scheduling/v1alpha1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
+	storagev1alpha1 "k8s.io/api/storage/v1alpha1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	storagemigrationv1alpha1 "k8s.io/api/storagemigration/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// builtinScheme has the built-in Kubernetes APIs registered in the client-go scheme.
+var builtinScheme = sync.OnceValue(func() *runtime.Scheme {
+	s := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		admissionregistrationv1.AddToScheme,
+		admissionregistrationv1alpha1.AddToScheme,
+		admissionregistrationv1beta1.AddToScheme,
+		apiserverinternalv1alpha1.AddToScheme,
+		appsv1.AddToScheme,
+		appsv1beta1.AddToScheme,
+		appsv1beta2.AddToScheme,
+		authenticationv1.AddToScheme,
+		authenticationv1alpha1.AddToScheme,
+		authenticationv1beta1.AddToScheme,
+		authorizationv1.AddToScheme,
+		authorizationv1beta1.AddToScheme,
+		autoscalingv1.AddToScheme,
+		autoscalingv2.AddToScheme,
+		autoscalingv2beta1.AddToScheme,
+		autoscalingv2beta2.AddToScheme,
+		batchv1.AddToScheme,
+		batchv1beta1.AddToScheme,
+		certificatesv1.AddToScheme,
+		certificatesv1alpha1.AddToScheme,
+		certificatesv1beta1.AddToScheme,
+		coordinationv1.AddToScheme,
+		coordinationv1alpha1.AddToScheme,
+		coordinationv1beta1.AddToScheme,
+		corev1.AddToScheme,
+		discoveryv1.AddToScheme,
+		discoveryv1beta1.AddToScheme,
+		eventsv1.AddToScheme,
+		eventsv1beta1.AddToScheme,
+		extensionsv1beta1.AddToScheme,
+		flowcontrolv1.AddToScheme,
+		flowcontrolv1beta1.AddToScheme,
+		flowcontrolv1beta2.AddToScheme,
+		flowcontrolv1beta3.AddToScheme,
+		networkingv1.AddToScheme,
+		networkingv1alpha1.AddToScheme,
+		networkingv1beta1.AddToScheme,
+		nodev1.AddToScheme,
+		nodev1alpha1.AddToScheme,
+		nodev1beta1.AddToScheme,
+		policyv1.AddToScheme,
+		policyv1beta1.AddToScheme,
+		rbacv1.AddToScheme,
+		rbacv1alpha1.AddToScheme,
+		rbacv1beta1.AddToScheme,
+		resourcev1alpha3.AddToScheme,
+		schedulingv1.AddToScheme,
+		schedulingv1alpha1.AddToScheme,
+		schedulingv1beta1.AddToScheme,
+		storagev1.AddToScheme,
+		storagev1alpha1.AddToScheme,
+		storagev1beta1.AddToScheme,
+		storagemigrationv1alpha1.AddToScheme,
+	} {
+		if err := add(s); err != nil {
+			panic(err)
+		}
+	}
+	return s
+})
+
+// metaPkgPath is the package of the meta kinds registered in every group version, e.g. WatchEvent.
+var metaPkgPath = reflect.TypeOf(metav1.Status{}).PkgPath()
+
+// introducedLifecycle and removedLifecycle are implemented by the built-in kinds of the prerelease
+// API versions, with the Kubernetes versions they are introduced and removed in.
+type (
+	introducedLifecycle interface {
+		APILifecycleIntroduced() (major, minor int)
+	}
+	removedLifecycle interface {
+		APILifecycleRemoved() (major, minor int)
+	}
+)
+
+// defaultAPIVersions returns the API versions and the API versions with kinds, e.g. "apps/v1" and
+// "apps/v1/Deployment", of the built-in Kubernetes APIs served by the Kubernetes version. Like
+// "helm template", they are the APIs of the client-go scheme, except the kinds not introduced yet
+// or removed in the version by their prerelease lifecycles.
+func defaultAPIVersions(kubeVersion *version.Version) VersionSet {
+	served := map[string]bool{}
+	for gvk, typ := range builtinScheme().AllKnownTypes() {
+		if typ.PkgPath() == metaPkgPath {
+			continue
+		}
+		obj := reflect.New(typ).Interface()
+		if l, ok := obj.(introducedLifecycle); ok && kubeVersion.LessThan(version.MajorMinor(lifecycleVersion(l.APILifecycleIntroduced()))) {
+			continue
+		}
+		if l, ok := obj.(removedLifecycle); ok && !kubeVersion.LessThan(version.MajorMinor(lifecycleVersion(l.APILifecycleRemoved()))) {
+			continue
+		}
+		gv := gvk.GroupVersion().String()
+		served[gv] = true
+		served[gv+"/"+gvk.Kind] = true
+	}
+
+	versions := make(VersionSet, 0, len(served))
+	for v := range served {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func lifecycleVersion(major, minor int) (uint, uint) {
+	return uint(major), uint(minor)
+}
@@ -0,0 +1,293 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package helm renders vendored Helm charts into Kusion resources inside a module, without a Helm
+// installation or network access.
+//
+// The rendering engine is compatible with "helm template" for the charts built on:
+//   - .Values, .Release, .Chart, .Template, .Files and .Capabilities
+//   - the sprig functions except env and expandenv, and the Helm functions toYaml, mustToYaml,
+//     fromYaml, fromYamlArray, toJson, mustToJson, fromJson, fromJsonArray, toToml, fromToml,
+//     include, tpl, required and lookup
+//   - the subcharts in the charts directory with the aliases, conditions, tags and global values
+//   - the .helmignore file of the chart directory, and the values decoded like Helm, in which the
+//     numbers are float64
+//
+// The following are not supported:
+//   - lookup always returns an empty map, as there is no cluster
+//   - .Capabilities.HelmVersion and .Subcharts
+//   - the import-values of dependencies and the values.schema.json validation
+//   - resolving the dependencies from repositories, the subcharts must be vendored
+//   - the hooks are rendered as ordinary manifests, except the test hooks which are skipped
+//
+// A template using anything unsupported fails to render rather than being rendered differently.
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// ChartFile is the file of the chart metadata.
+	ChartFile = "Chart.yaml"
+	// ValuesFile is the file of the default values of the chart.
+	ValuesFile = "values.yaml"
+	// TemplatesDir is the directory of the chart templates.
+	TemplatesDir = "templates"
+	// ChartsDir is the directory of the subcharts.
+	ChartsDir = "charts"
+)
+
+// ChartTypeLibrary is the type of the charts providing only named templates.
+const ChartTypeLibrary = "library"
+
+// ErrInvalidChart means the chart can't be loaded.
+var ErrInvalidChart = errors.New("invalid chart")
+
+// Metadata is the metadata of a chart in Chart.yaml, available in templates by .Chart.
+type Metadata struct {
+	APIVersion   string            `yaml:"apiVersion"`
+	Name         string            `yaml:"name"`
+	Version      string            `yaml:"version"`
+	KubeVersion  string            `yaml:"kubeVersion,omitempty"`
+	AppVersion   string            `yaml:"appVersion,omitempty"`
+	Description  string            `yaml:"description,omitempty"`
+	Type         string            `yaml:"type,omitempty"`
+	Keywords     []string          `yaml:"keywords,omitempty"`
+	Home         string            `yaml:"home,omitempty"`
+	Sources      []string          `yaml:"sources,omitempty"`
+	Maintainers  []*Maintainer     `yaml:"maintainers,omitempty"`
+	Icon         string            `yaml:"icon,omitempty"`
+	Condition    string            `yaml:"condition,omitempty"`
+	Tags         string            `yaml:"tags,omitempty"`
+	Deprecated   bool              `yaml:"deprecated,omitempty"`
+	Annotations  map[string]string `yaml:"annotations,omitempty"`
+	Dependencies []*Dependency     `yaml:"dependencies,omitempty"`
+}
+
+// Maintainer is a maintainer of a chart.
+type Maintainer struct {
+	Name  string `yaml:"name,omitempty"`
+	Email string `yaml:"email,omitempty"`
+	URL   string `yaml:"url,omitempty"`
+}
+
+// Dependency is a subchart declared in Chart.yaml.
+type Dependency struct {
+	Name       string   `yaml:"name"`
+	Version    string   `yaml:"version,omitempty"`
+	Repository string   `yaml:"repository,omitempty"`
+	Alias      string   `yaml:"alias,omitempty"`
+	Condition  string   `yaml:"condition,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"`
+}
+
+// File is a file of a chart, the name is relative to the chart root, e.g. "templates/service.yaml".
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Chart is a loaded Helm chart.
+type Chart struct {
+	Metadata *Metadata
+	// Values are the default values in values.yaml.
+	Values map[string]any
+	// Templates are the files in the templates directory.
+	Templates []*File
+	// Files are the other files of the chart, available in templates by .Files.
+	Files []*File
+	// Dependencies are the subcharts in the charts directory.
+	Dependencies []*Chart
+}
+
+// Name returns the name of the chart.
+func (c *Chart) Name() string {
+	return c.Metadata.Name
+}
+
+// LoadChart loads the chart from a directory or a packaged chart archive, e.g. "charts/redis" and
+// "charts/redis-18.0.0.tgz". The subcharts must be vendored in the charts directory, unpacked or
+// packaged.
+func LoadChart(name string) (*Chart, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return LoadDir(name)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadArchive(data)
+}
+
+// LoadDir loads the chart from the directory, the files matching the patterns in the .helmignore file
+// of the chart are skipped.
+func LoadDir(dir string) (*Chart, error) {
+	ignore, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load chart %s failed: %w", dir, err)
+	}
+	rules, err := parseIgnoreRules(ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*File
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rules.ignored(filepath.ToSlash(rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, &File{Name: filepath.ToSlash(rel), Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load chart %s failed: %w", dir, err)
+	}
+	return loadFiles(files)
+}
+
+// LoadArchive loads the chart from the gzipped tar archive created by "helm package", in which the
+// files are in a directory named after the chart.
+func LoadArchive(data []byte) (*Chart, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChart, err)
+	}
+	defer gz.Close()
+
+	var files []*File
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChart, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// strip the directory of the chart
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		_, rel, found := strings.Cut(name, "/")
+		if !found || rel == "" || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("%w: illegal file %s in archive", ErrInvalidChart, hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChart, err)
+		}
+		files = append(files, &File{Name: rel, Data: content})
+	}
+	return loadFiles(files)
+}
+
+// loadFiles builds the chart from the files relative to the chart root.
+func loadFiles(files []*File) (*Chart, error) {
+	c := &Chart{}
+	subcharts := map[string][]*File{}
+	for _, f := range files {
+		switch {
+		case f.Name == ChartFile:
+			c.Metadata = &Metadata{}
+			if err := yaml.Unmarshal(f.Data, c.Metadata); err != nil {
+				return nil, fmt.Errorf("%w: parse %s failed: %v", ErrInvalidChart, ChartFile, err)
+			}
+		case f.Name == ValuesFile:
+			// decoded through JSON like Helm, so the numbers are float64
+			if err := sigsyaml.Unmarshal(f.Data, &c.Values); err != nil {
+				return nil, fmt.Errorf("%w: parse %s failed: %v", ErrInvalidChart, ValuesFile, err)
+			}
+		case strings.HasPrefix(f.Name, TemplatesDir+"/"):
+			c.Templates = append(c.Templates, f)
+		case strings.HasPrefix(f.Name, ChartsDir+"/"):
+			rel := strings.TrimPrefix(f.Name, ChartsDir+"/")
+			if dir, sub, found := strings.Cut(rel, "/"); found {
+				subcharts[dir] = append(subcharts[dir], &File{Name: sub, Data: f.Data})
+				continue
+			}
+			if strings.HasSuffix(rel, ".tgz") || strings.HasSuffix(rel, ".tar.gz") {
+				sc, err := LoadArchive(f.Data)
+				if err != nil {
+					return nil, fmt.Errorf("load subchart %s failed: %w", rel, err)
+				}
+				c.Dependencies = append(c.Dependencies, sc)
+				continue
+			}
+			c.Files = append(c.Files, f)
+		default:
+			c.Files = append(c.Files, f)
+		}
+	}
+	if c.Metadata == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidChart, ChartFile)
+	}
+	if c.Metadata.Name == "" {
+		return nil, fmt.Errorf("%w: missing chart name in %s", ErrInvalidChart, ChartFile)
+	}
+
+	dirs := make([]string, 0, len(subcharts))
+	for dir := range subcharts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		sc, err := loadFiles(subcharts[dir])
+		if err != nil {
+			return nil, fmt.Errorf("load subchart %s failed: %w", dir, err)
+		}
+		c.Dependencies = append(c.Dependencies, sc)
+	}
+
+	sort.Slice(c.Templates, func(i, j int) bool { return c.Templates[i].Name < c.Templates[j].Name })
+	sort.Slice(c.Files, func(i, j int) bool { return c.Files[i].Name < c.Files[j].Name })
+	sort.Slice(c.Dependencies, func(i, j int) bool { return c.Dependencies[i].Name() < c.Dependencies[j].Name() })
+	return c, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"encoding/base64"
+	"path"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// Files are the non-template files of a chart available in templates by .Files, the names are
+// relative to the chart root, e.g. "config/app.conf".
+type Files map[string][]byte
+
+// Get returns the content of the file, or an empty string if it doesn't exist.
+func (f Files) Get(name string) string {
+	return string(f[name])
+}
+
+// GetBytes returns the content of the file, or nil if it doesn't exist.
+func (f Files) GetBytes(name string) []byte {
+	return f[name]
+}
+
+// Glob returns the files matching the glob pattern, in which "**" matches across directories, e.g.
+// "config/**.conf". An invalid pattern matches no file.
+func (f Files) Glob(pattern string) Files {
+	matched := Files{}
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return matched
+	}
+	for name, data := range f {
+		if g.Match(name) {
+			matched[name] = data
+		}
+	}
+	return matched
+}
+
+// AsConfig returns the YAML of the file contents by the base names, to be the data of a ConfigMap.
+func (f Files) AsConfig() string {
+	if len(f) == 0 {
+		return ""
+	}
+	m := make(map[string]string, len(f))
+	for name, data := range f {
+		m[path.Base(name)] = string(data)
+	}
+	return toYAML(m)
+}
+
+// AsSecrets returns the YAML of the base64 encoded file contents by the base names, to be the data
+// of a Secret.
+func (f Files) AsSecrets() string {
+	if len(f) == 0 {
+		return ""
+	}
+	m := make(map[string]string, len(f))
+	for name, data := range f {
+		m[path.Base(name)] = base64.StdEncoding.EncodeToString(data)
+	}
+	return toYAML(m)
+}
+
+// Lines returns the lines of the file, or an empty list if it doesn't exist.
+func (f Files) Lines(name string) []string {
+	s := string(f[name])
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
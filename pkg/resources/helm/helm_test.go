@@ -0,0 +1,402 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+const chartDir = "testdata/nginx"
+
+func options() *Options {
+	platformConfig := v1.GenericConfig{"replicaCount": 2, "global": map[string]any{"registry": "registry.example.com"}}
+	devConfig := v1.Accessory{"image": map[string]any{"tag": "1.26.0"}, "podAnnotations": map[string]any{"a": "b"}}
+	return &Options{
+		ReleaseName: "web",
+		Namespace:   "prod",
+		Values:      MergeValues(platformConfig, devConfig),
+	}
+}
+
+func packageChart(t *testing.T, dir string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(dir), p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestLoadChart(t *testing.T) {
+	c, err := LoadChart(chartDir)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", c.Name())
+	assert.Equal(t, "1.25.0", c.Metadata.AppVersion)
+	assert.Len(t, c.Templates, 5)
+	require.Len(t, c.Dependencies, 1)
+	assert.Equal(t, "redis", c.Dependencies[0].Name())
+	assert.Equal(t, map[string]any{"port": float64(6379)}, c.Dependencies[0].Values)
+
+	archived, err := LoadArchive(packageChart(t, chartDir))
+	require.NoError(t, err)
+	assert.Equal(t, c, archived)
+
+	archive := filepath.Join(t.TempDir(), "nginx-0.1.0.tgz")
+	require.NoError(t, os.WriteFile(archive, packageChart(t, chartDir), 0o600))
+	archived, err = LoadChart(archive)
+	require.NoError(t, err)
+	assert.Equal(t, c, archived)
+
+	_, err = LoadDir(filepath.Join(chartDir, "templates"))
+	assert.ErrorIs(t, err, ErrInvalidChart)
+}
+
+func TestLoadDirIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":              "name: test\nversion: 0.1.0\n",
+		"values.yaml":             "replicas: 2\n",
+		".helmignore":             "# comments\n*.bak\n/docs/\nconfig/*.local\n",
+		"README.md":               "readme",
+		"values.yaml.bak":         "backup",
+		"docs/usage.md":           "usage",
+		"config/app.conf":         "a=1",
+		"config/app.local":        "a=2",
+		"templates/a.yaml":        "kind: {{ kindOf .Values.replicas }}",
+		"templates/.a.yaml.swp":   "swap",
+		"charts/sub/Chart.yaml":   "name: sub\nversion: 0.1.0\n",
+		"charts/sub/values.bak":   "backup",
+		"charts/sub/values.yaml":  "port: 80\n",
+		"charts/sub/docs/help.md": "help",
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o600))
+	}
+
+	c, err := LoadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range c.Files {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{".helmignore", "README.md", "config/app.conf"}, names)
+	require.Len(t, c.Templates, 1)
+	require.Len(t, c.Dependencies, 1)
+	names = nil
+	for _, f := range c.Dependencies[0].Files {
+		names = append(names, f.Name)
+	}
+	// the patterns without "/" match the base names in the subcharts too
+	assert.Equal(t, []string{"docs/help.md"}, names)
+
+	// the numbers in the values are float64 like Helm
+	manifests, err := Render(c, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "kind: float64", manifests["test/templates/a.yaml"])
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("docs/**\n"), 0o600))
+	_, err = LoadDir(dir)
+	assert.ErrorIs(t, err, ErrInvalidChart)
+}
+
+func TestRender(t *testing.T) {
+	c, err := LoadChart(chartDir)
+	require.NoError(t, err)
+
+	opts := options()
+	opts.Values = MergeValues(opts.Values, map[string]any{"cache": map[string]any{"enabled": true, "port": 6380}})
+	manifests, err := Render(c, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"nginx/charts/cache/templates/service.yaml",
+		"nginx/charts/redis/templates/service.yaml",
+		"nginx/templates/deployment.yaml",
+		"nginx/templates/service.yaml",
+		"nginx/templates/tests.yaml",
+	}, sortedNames(manifests))
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-nginx
+  labels:
+    app.kubernetes.io/name: nginx
+    app.kubernetes.io/instance: web
+    app.kubernetes.io/managed-by: Helm
+spec:
+  replicas: 2
+  template:
+    metadata:
+      annotations:
+        a: b
+      labels:
+        app.kubernetes.io/name: nginx
+        app.kubernetes.io/instance: web
+        app.kubernetes.io/managed-by: Helm
+    spec:
+      containers:
+        - name: nginx
+          image: "registry.example.com/nginx:1.26.0"
+          ports:
+            - containerPort: 80`, manifests["nginx/templates/deployment.yaml"])
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: web-cache
+  annotations:
+    registry: registry.example.com
+spec:
+  ports:
+    - port: 6380`, manifests["nginx/charts/cache/templates/service.yaml"])
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "required",
+			template: `name: {{ required "name is required" .Values.name }}`,
+			err:      "name is required",
+		},
+		{
+			name:     "parse",
+			template: `name: {{ .Values.name`,
+			err:      "parse template test/templates/a.yaml failed",
+		},
+		{
+			name:     "recursive include",
+			template: `{{ define "loop" }}{{ include "loop" . }}{{ end }}{{ include "loop" . }}`,
+			err:      "rendering template has a nested reference name: loop",
+		},
+		{
+			name:     "env is not allowed",
+			template: `home: {{ env "HOME" }}`,
+			err:      `function "env" not defined`,
+		},
+		{
+			name:     "helm version is not supported",
+			template: `helm: {{ .Capabilities.HelmVersion.Version }}`,
+			err:      "can't evaluate field HelmVersion",
+		},
+		{
+			name:     "subcharts are not supported",
+			template: `redis: {{ .Subcharts.redis.Values }}`,
+			err:      "render template test/templates/a.yaml failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Chart{
+				Metadata:  &Metadata{Name: "test"},
+				Templates: []*File{{Name: "templates/a.yaml", Data: []byte(tt.template)}},
+			}
+			_, err := Render(c, nil)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestRenderFunctions(t *testing.T) {
+	c := &Chart{
+		Metadata: &Metadata{Name: "test"},
+		Values:   map[string]any{"name": "foo", "suffix": "{{ .Values.name }}-bar", "labels": map[string]any{"a": "b"}},
+		Templates: []*File{{Name: "templates/a.yaml", Data: []byte(`name: {{ tpl .Values.suffix . }}
+labels: {{ .Values.labels | toJson }}
+missing: {{ .Values.missing }}
+secret: {{ lookup "v1" "Secret" "default" "foo" | len }}
+kube: {{ .Capabilities.KubeVersion.Minor }}
+{{- if .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" }}
+monitoring: true
+{{- end }}`)}},
+	}
+	manifests, err := Render(c, &Options{KubeVersion: "1.29.3", APIVersions: []string{"monitoring.coreos.com/v1"}})
+	require.NoError(t, err)
+	assert.Equal(t, `name: foo-bar
+labels: {"a":"b"}
+missing: 
+secret: 0
+kube: 29
+monitoring: true`, manifests["test/templates/a.yaml"])
+}
+
+func TestRenderCapabilities(t *testing.T) {
+	c := &Chart{
+		Metadata: &Metadata{Name: "test"},
+		Templates: []*File{{Name: "templates/a.yaml", Data: []byte(`
+{{- range $v := list "v1" "apps/v1/Deployment" "policy/v1" "policy/v1beta1" "policy/v1beta1/PodDisruptionBudget" "networking.k8s.io/v1/Ingress" "extensions/v1beta1/Ingress" "autoscaling/v2" "monitoring.coreos.com/v1" }}
+{{ $v }}: {{ $.Capabilities.APIVersions.Has $v }}
+{{- end }}`)}},
+	}
+
+	manifests, err := Render(c, &Options{APIVersions: []string{"monitoring.coreos.com/v1"}})
+	require.NoError(t, err)
+	assert.Equal(t, `v1: true
+apps/v1/Deployment: true
+policy/v1: true
+policy/v1beta1: false
+policy/v1beta1/PodDisruptionBudget: false
+networking.k8s.io/v1/Ingress: true
+extensions/v1beta1/Ingress: false
+autoscaling/v2: true
+monitoring.coreos.com/v1: true`, manifests["test/templates/a.yaml"])
+
+	// the removed beta APIs are served by the old Kubernetes versions
+	manifests, err = Render(c, &Options{KubeVersion: "v1.21.0"})
+	require.NoError(t, err)
+	assert.Contains(t, manifests["test/templates/a.yaml"], "policy/v1beta1/PodDisruptionBudget: true")
+	assert.Contains(t, manifests["test/templates/a.yaml"], "extensions/v1beta1/Ingress: true")
+}
+
+func TestRenderChartAndFiles(t *testing.T) {
+	c := &Chart{
+		Metadata: &Metadata{
+			Name:        "test",
+			Home:        "https://example.com",
+			Keywords:    []string{"web", "nginx"},
+			Annotations: map[string]string{"category": "Web"},
+		},
+		Templates: []*File{{Name: "templates/a.yaml", Data: []byte(`home: {{ .Chart.Home }}
+keywords: {{ join "," .Chart.Keywords }}
+category: {{ index .Chart.Annotations "category" }}
+config:
+  {{- (.Files.Glob "config/**.conf").AsConfig | nindent 2 }}
+secrets:
+  {{- (.Files.Glob "config/*.key").AsSecrets | nindent 2 }}
+none: {{ (.Files.Glob "missing/*").AsConfig | quote }}
+{{- range .Files.Lines "hosts.txt" }}
+host: {{ . }}
+{{- end }}
+lines: {{ .Files.Lines "missing.txt" | len }}
+toml: {{ dict "port" 80 | toToml | trim }}
+fromToml: {{ (fromToml "port = 80").port }}`)}},
+		Files: []*File{
+			{Name: "config/app.conf", Data: []byte("a=1\n")},
+			{Name: "config/nested/db.conf", Data: []byte("b=2\n")},
+			{Name: "config/tls.key", Data: []byte("key")},
+			{Name: "hosts.txt", Data: []byte("a.example.com\nb.example.com\n")},
+		},
+	}
+	manifests, err := Render(c, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `home: https://example.com
+keywords: web,nginx
+category: Web
+config:
+  app.conf: |
+    a=1
+  db.conf: |
+    b=2
+secrets:
+  tls.key: a2V5
+none: ""
+host: a.example.com
+host: b.example.com
+lines: 0
+toml: port = 80
+fromToml: 80`, manifests["test/templates/a.yaml"])
+}
+
+func TestRenderDependencyTags(t *testing.T) {
+	sub := func(name string) *Chart {
+		return &Chart{
+			Metadata:  &Metadata{Name: name},
+			Templates: []*File{{Name: "templates/a.yaml", Data: []byte("name: " + name)}},
+		}
+	}
+	c := &Chart{
+		Metadata: &Metadata{Name: "test", Dependencies: []*Dependency{
+			{Name: "backend", Tags: []string{"api"}},
+			{Name: "frontend", Tags: []string{"ui", "web"}},
+			{Name: "cache", Condition: "cache.enabled", Tags: []string{"api"}},
+			{Name: "untagged"},
+		}},
+		Values:       map[string]any{"tags": map[string]any{"api": false, "ui": false, "web": true}, "cache": map[string]any{"enabled": true}},
+		Dependencies: []*Chart{sub("backend"), sub("frontend"), sub("cache"), sub("untagged")},
+	}
+	manifests, err := Render(c, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"test/charts/cache/templates/a.yaml",
+		"test/charts/frontend/templates/a.yaml",
+		"test/charts/untagged/templates/a.yaml",
+	}, sortedNames(manifests))
+}
+
+func TestRenderOverrideSubchartTemplates(t *testing.T) {
+	sub := &Chart{
+		Metadata: &Metadata{Name: "common"},
+		Templates: []*File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "common.name" }}common{{ end }}`)},
+			{Name: "templates/a.yaml", Data: []byte(`name: {{ include "common.name" . }}`)},
+		},
+	}
+	c := &Chart{
+		Metadata: &Metadata{Name: "test"},
+		Templates: []*File{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "common.name" }}test{{ end }}`)},
+			{Name: "templates/b.yaml", Data: []byte(`name: {{ include "common.name" . }}`)},
+		},
+		Dependencies: []*Chart{sub},
+	}
+	manifests, err := Render(c, nil)
+	require.NoError(t, err)
+	// the named templates of the parent override the ones of the subcharts like Helm
+	assert.Equal(t, map[string]string{
+		"test/templates/b.yaml":               "name: test",
+		"test/charts/common/templates/a.yaml": "name: test",
+	}, manifests)
+}
+
+func TestGenerate(t *testing.T) {
+	resources, err := Generate(chartDir, options())
+	require.NoError(t, err)
+
+	var ids []string
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+		assert.Equal(t, v1.Kubernetes, r.Type)
+	}
+	assert.Equal(t, []string{
+		"v1:Service:prod:web-redis",
+		"apps/v1:Deployment:prod:web-nginx",
+		"v1:Service:prod:web-nginx",
+		"rbac.authorization.k8s.io/v1:ClusterRole:web-nginx",
+	}, ids)
+
+	deployment := resources[1]
+	assert.Equal(t, "apps/v1, Kind=Deployment", deployment.Extensions[v1.ResourceExtensionGVK])
+	assert.Equal(t, 2, deployment.Attributes["spec"].(map[string]any)["replicas"])
+	assert.Equal(t, "prod", deployment.Attributes["metadata"].(map[string]any)["namespace"])
+}
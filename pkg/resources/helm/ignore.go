@@ -0,0 +1,110 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// IgnoreFile is the file of the patterns of the files ignored when loading a chart directory.
+const IgnoreFile = ".helmignore"
+
+// defaultIgnorePatterns are always ignored like Helm, which are the hidden files in the templates.
+var defaultIgnorePatterns = []string{"templates/.?*"}
+
+// ignorePattern is a pattern in the .helmignore file.
+type ignorePattern struct {
+	raw     string
+	negate  bool
+	mustDir bool
+	// full matches the full path instead of the base name
+	full bool
+}
+
+// ignoreRules are the patterns in the .helmignore file, following the rules of Helm:
+//   - the blank lines and the lines starting with "#" are skipped
+//   - the patterns are the path.Match patterns, "**" is not supported
+//   - the patterns containing "/" match the relative paths, others match the base names
+//   - the patterns ending with "/" only match the directories
+//   - the patterns starting with "!" negate the match
+type ignoreRules []*ignorePattern
+
+// parseIgnoreRules parses the content of the .helmignore file.
+func parseIgnoreRules(data []byte) (ignoreRules, error) {
+	var rules ignoreRules
+	for _, raw := range defaultIgnorePatterns {
+		rules = append(rules, &ignorePattern{raw: raw, full: true})
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "**") {
+			return nil, fmt.Errorf("%w: double-star (**) syntax is not supported in %s", ErrInvalidChart, IgnoreFile)
+		}
+		p := &ignorePattern{raw: line}
+		if strings.HasPrefix(p.raw, "!") && len(p.raw) > 1 {
+			p.negate = true
+			p.raw = p.raw[1:]
+		}
+		if strings.HasSuffix(p.raw, "/") {
+			p.mustDir = true
+			p.raw = strings.TrimSuffix(p.raw, "/")
+		}
+		if strings.Contains(p.raw, "/") {
+			p.full = true
+			p.raw = strings.TrimPrefix(p.raw, "/")
+		}
+		if _, err := path.Match(p.raw, ""); err != nil {
+			return nil, fmt.Errorf("%w: malformed pattern %q in %s", ErrInvalidChart, line, IgnoreFile)
+		}
+		rules = append(rules, p)
+	}
+	return rules, scanner.Err()
+}
+
+// ignored returns whether the file or directory at the slash-separated path relative to the chart
+// root is ignored.
+func (r ignoreRules) ignored(p string, dir bool) bool {
+	if p == "" || p == "." {
+		return false
+	}
+	for _, pattern := range r {
+		name := path.Base(p)
+		if pattern.full {
+			name = p
+		}
+		matched, _ := path.Match(pattern.raw, name)
+		if pattern.negate {
+			// like Helm, a negated pattern ignores everything it doesn't match
+			if pattern.mustDir && !dir || !matched {
+				return true
+			}
+			continue
+		}
+		if pattern.mustDir && !dir {
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,511 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/sprig/v3"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/yaml"
+
+	"kusionstack.io/kusion-module-framework/pkg/util/workspace"
+)
+
+const (
+	// DefaultKubeVersion is the Kubernetes version in .Capabilities if it is not specified.
+	DefaultKubeVersion = "v1.31.0"
+	// releaseService is the service rendering the release in .Release.Service.
+	releaseService = "Helm"
+	// maxIncludeDepth is the maximum depth of the nested include and tpl calls.
+	maxIncludeDepth = 1000
+	// notesFile is the file of the release notes, which is not rendered.
+	notesFile = "NOTES.txt"
+)
+
+// Options are the options to render a chart.
+type Options struct {
+	// ReleaseName is the name of the release, usually the application name.
+	ReleaseName string
+	// Namespace is the namespace of the release.
+	Namespace string
+	// Values override the default values of the chart, e.g. the values merged from the DevConfig and
+	// the PlatformConfig of the GeneratorRequest by MergeValues.
+	Values map[string]any
+	// KubeVersion is the Kubernetes version in .Capabilities, DefaultKubeVersion is used if empty.
+	KubeVersion string
+	// APIVersions are the API versions in .Capabilities.APIVersions in addition to the built-in
+	// Kubernetes APIs served by the KubeVersion, e.g. "monitoring.coreos.com/v1" and
+	// "monitoring.coreos.com/v1/ServiceMonitor".
+	APIVersions []string
+}
+
+// Release is the release information available in templates by .Release.
+type Release struct {
+	Name      string
+	Namespace string
+	Revision  int
+	IsInstall bool
+	IsUpgrade bool
+	Service   string
+}
+
+// Capabilities are the cluster capabilities available in templates by .Capabilities.
+type Capabilities struct {
+	KubeVersion KubeVersion
+	APIVersions VersionSet
+}
+
+// KubeVersion is the Kubernetes version in .Capabilities.
+type KubeVersion struct {
+	Version string
+	Major   string
+	Minor   string
+}
+
+// String returns the Kubernetes version, e.g. "v1.31.0".
+func (v KubeVersion) String() string {
+	return v.Version
+}
+
+// GitVersion returns the Kubernetes version, it is deprecated in Helm.
+func (v KubeVersion) GitVersion() string {
+	return v.Version
+}
+
+// VersionSet is the set of API versions in .Capabilities.APIVersions.
+type VersionSet []string
+
+// Has returns whether the API version or the API version and kind is available.
+func (s VersionSet) Has(apiVersion string) bool {
+	for _, v := range s {
+		if v == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateInfo is the information of the rendering template available in templates by .Template.
+type TemplateInfo struct {
+	Name     string
+	BasePath string
+}
+
+// MergeValues merges the values in order like the "--values" flags of Helm: maps are merged
+// recursively, null values delete the keys, and other values are replaced.
+func MergeValues(values ...map[string]any) map[string]any {
+	var merged map[string]any
+	for _, v := range values {
+		merged = workspace.MergeGenericConfigs(merged, v, nil)
+	}
+	return merged
+}
+
+// scope is a chart, or a subchart with its alias, rendered with its own values.
+type scope struct {
+	chart    *Chart
+	metadata *Metadata
+	values   map[string]any
+	path     string
+}
+
+// Render renders the templates of the chart and its enabled subcharts like "helm template" without a
+// cluster, so the lookup function always returns an empty map. It returns the rendered manifests by
+// the template paths, e.g. "redis/templates/service.yaml" and "redis/charts/common/templates/a.yaml".
+// Partial templates prefixed with "_", NOTES.txt and the templates rendering nothing are omitted.
+func Render(c *Chart, opts *Options) (map[string]string, error) {
+	if c == nil || c.Metadata == nil {
+		return nil, fmt.Errorf("%w: empty chart", ErrInvalidChart)
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	caps, err := capabilities(opts)
+	if err != nil {
+		return nil, err
+	}
+	release := Release{
+		Name:      opts.ReleaseName,
+		Namespace: opts.Namespace,
+		Revision:  1,
+		IsInstall: true,
+		Service:   releaseService,
+	}
+
+	values := MergeValues(c.Values, opts.Values)
+	if values == nil {
+		values = map[string]any{}
+	}
+	var scopes []*scope
+	if err = collectScopes(c, c.Metadata, values, c.Name(), &scopes); err != nil {
+		return nil, err
+	}
+
+	templates := map[string]string{}
+	for _, s := range scopes {
+		for _, f := range s.chart.Templates {
+			templates[path.Join(s.path, f.Name)] = string(f.Data)
+		}
+	}
+	e := &engine{}
+	e.t = template.New("gotpl").Option("missingkey=zero").Funcs(e.funcMap())
+	for _, name := range sortTemplates(templates) {
+		if _, err = e.t.New(name).Parse(templates[name]); err != nil {
+			return nil, fmt.Errorf("parse template %s failed: %w", name, err)
+		}
+	}
+
+	manifests := map[string]string{}
+	for _, s := range scopes {
+		if s.metadata.Type == ChartTypeLibrary {
+			continue
+		}
+		files := Files{}
+		for _, f := range s.chart.Files {
+			files[f.Name] = f.Data
+		}
+		for _, f := range s.chart.Templates {
+			base := path.Base(f.Name)
+			if strings.HasPrefix(base, "_") || base == notesFile {
+				continue
+			}
+			name := path.Join(s.path, f.Name)
+			data := map[string]any{
+				"Values":       s.values,
+				"Release":      release,
+				"Chart":        s.metadata,
+				"Capabilities": caps,
+				"Files":        files,
+				"Template":     TemplateInfo{Name: name, BasePath: path.Join(s.path, TemplatesDir)},
+			}
+			var buf strings.Builder
+			if err = e.t.ExecuteTemplate(&buf, name, data); err != nil {
+				return nil, fmt.Errorf("render template %s failed: %w", name, err)
+			}
+			manifest := strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", ""))
+			if manifest != "" {
+				manifests[name] = manifest
+			}
+		}
+	}
+	return manifests, nil
+}
+
+func capabilities(opts *Options) (*Capabilities, error) {
+	kubeVersion := opts.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = DefaultKubeVersion
+	}
+	v, err := version.ParseGeneric(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kube version %s: %w", kubeVersion, err)
+	}
+	apiVersions := defaultAPIVersions(v)
+	for _, extra := range opts.APIVersions {
+		if !apiVersions.Has(extra) {
+			apiVersions = append(apiVersions, extra)
+		}
+	}
+	return &Capabilities{
+		KubeVersion: KubeVersion{
+			Version: "v" + v.String(),
+			Major:   fmt.Sprint(v.Major()),
+			Minor:   fmt.Sprint(v.Minor()),
+		},
+		APIVersions: apiVersions,
+	}, nil
+}
+
+// sortTemplates returns the template names in the parsing order of Helm, the deepest first and the
+// same depth in reverse lexical order, so the named templates defined by a chart override the ones
+// of the same names defined by its subcharts.
+func sortTemplates(templates map[string]string) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "/"), strings.Count(names[j], "/")
+		if di != dj {
+			return di > dj
+		}
+		return names[i] > names[j]
+	})
+	return names
+}
+
+// collectScopes collects the chart and its enabled subcharts. The values of a subchart are the
+// values under its name or alias, merged over its default values, and the global values are passed
+// down to all subcharts.
+func collectScopes(c *Chart, metadata *Metadata, values map[string]any, p string, scopes *[]*scope) error {
+	*scopes = append(*scopes, &scope{chart: c, metadata: metadata, values: values, path: p})
+	globals, _ := values["global"].(map[string]any)
+
+	for _, sc := range c.Dependencies {
+		deps := dependencies(c.Metadata, sc.Name())
+		for _, dep := range deps {
+			if !enabled(values, dep) {
+				continue
+			}
+			key := dep.Name
+			md := *sc.Metadata
+			if dep.Alias != "" {
+				key = dep.Alias
+				md.Name = dep.Alias
+			}
+			subValues, _ := values[key].(map[string]any)
+			if len(globals) != 0 {
+				subValues = MergeValues(subValues, map[string]any{"global": globals})
+			}
+			merged := MergeValues(sc.Values, subValues)
+			if merged == nil {
+				merged = map[string]any{}
+			}
+			values[key] = merged
+			if err := collectScopes(sc, &md, merged, path.Join(p, ChartsDir, key), scopes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dependencies returns the dependencies of the subchart declared in the metadata, or a dependency
+// named after the subchart if it is not declared.
+func dependencies(metadata *Metadata, name string) []*Dependency {
+	var deps []*Dependency
+	for _, dep := range metadata.Dependencies {
+		if dep.Name == name {
+			deps = append(deps, dep)
+		}
+	}
+	if len(deps) == 0 {
+		deps = append(deps, &Dependency{Name: name})
+	}
+	return deps
+}
+
+// enabled evaluates the condition of a dependency, which is a comma separated list of value paths,
+// e.g. "redis.enabled,global.redis.enabled". The first path found decides. If no path is found, the
+// dependency is disabled if some of its tags are false in the "tags" values and none is true,
+// otherwise it is enabled.
+func enabled(values map[string]any, dep *Dependency) bool {
+	for _, p := range strings.Split(dep.Condition, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		var current any = values
+		for _, key := range strings.Split(p, ".") {
+			m, ok := current.(map[string]any)
+			if !ok {
+				current = nil
+				break
+			}
+			current = m[key]
+		}
+		if b, ok := current.(bool); ok {
+			return b
+		}
+	}
+
+	tags, _ := values["tags"].(map[string]any)
+	hasTrue, hasFalse := false, false
+	for _, tag := range dep.Tags {
+		if b, ok := tags[tag].(bool); ok {
+			hasTrue = hasTrue || b
+			hasFalse = hasFalse || !b
+		}
+	}
+	return hasTrue || !hasFalse
+}
+
+// engine executes the templates with the functions of Helm.
+type engine struct {
+	t     *template.Template
+	depth int
+}
+
+func (e *engine) funcMap() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	// the environment of the module must not leak into the manifests
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+
+	funcs["toYaml"] = toYAML
+	funcs["mustToYaml"] = mustToYAML
+	funcs["fromYaml"] = fromYAML
+	funcs["fromYamlArray"] = fromYAMLArray
+	funcs["toJson"] = toJSON
+	funcs["mustToJson"] = mustToJSON
+	funcs["fromJson"] = fromJSON
+	funcs["fromJsonArray"] = fromJSONArray
+	funcs["toToml"] = toTOML
+	funcs["fromToml"] = fromTOML
+	funcs["required"] = required
+	funcs["lookup"] = func(string, string, string, string) (map[string]any, error) {
+		return map[string]any{}, nil
+	}
+	funcs["include"] = e.include
+	funcs["tpl"] = e.tpl
+	return funcs
+}
+
+func (e *engine) include(name string, data any) (string, error) {
+	if e.depth >= maxIncludeDepth {
+		return "", fmt.Errorf("rendering template has a nested reference name: %s", name)
+	}
+	e.depth++
+	defer func() { e.depth-- }()
+
+	var buf strings.Builder
+	if err := e.t.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (e *engine) tpl(text string, data any) (string, error) {
+	if e.depth >= maxIncludeDepth {
+		return "", errors.New("rendering template has too many nested tpl calls")
+	}
+	e.depth++
+	defer func() { e.depth-- }()
+
+	t, err := e.t.Clone()
+	if err != nil {
+		return "", err
+	}
+	if t, err = t.New("tpl").Parse(text); err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if err = t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+func required(msg string, v any) (any, error) {
+	if v == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+// toYAML returns the YAML of the value without the trailing newline, or an empty string if it fails
+// like Helm.
+func toYAML(v any) string {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+func mustToYAML(v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func fromYAML(s string) map[string]any {
+	m := map[string]any{}
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		m["Error"] = err.Error()
+	}
+	return m
+}
+
+func fromYAMLArray(s string) []any {
+	var a []any
+	if err := yaml.Unmarshal([]byte(s), &a); err != nil {
+		a = []any{err.Error()}
+	}
+	return a
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func mustToJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func fromJSON(s string) map[string]any {
+	m := map[string]any{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		m["Error"] = err.Error()
+	}
+	return m
+}
+
+func fromJSONArray(s string) []any {
+	var a []any
+	if err := json.Unmarshal([]byte(s), &a); err != nil {
+		a = []any{err.Error()}
+	}
+	return a
+}
+
+// toTOML returns the TOML of the value, or the error message if it fails like Helm.
+func toTOML(v any) string {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+func fromTOML(s string) map[string]any {
+	m := map[string]any{}
+	if err := toml.Unmarshal([]byte(s), &m); err != nil {
+		m["Error"] = err.Error()
+	}
+	return m
+}
+
+// sortedNames returns the sorted template paths of the manifests.
+func sortedNames(manifests map[string]string) []string {
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
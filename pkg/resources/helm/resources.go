@@ -0,0 +1,82 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources/kubernetes"
+)
+
+// AnnotationHook is the annotation of the Helm hooks.
+const AnnotationHook = "helm.sh/hook"
+
+// Generate loads the chart from the directory or archive, renders it and converts the manifests into
+// Kusion resources, in which the namespaced objects without a namespace are in opts.Namespace.
+func Generate(chartPath string, opts *Options) (v1.Resources, error) {
+	c, err := LoadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := Render(c, opts)
+	if err != nil {
+		return nil, err
+	}
+	namespace := ""
+	if opts != nil {
+		namespace = opts.Namespace
+	}
+	return ToKusionResources(manifests, namespace)
+}
+
+// ToKusionResources converts the manifests rendered by Render into Kusion resources, in the order of
+// the template paths and the documents in the manifests. The namespaced objects without a namespace
+// are put in the namespace, and the test hooks are skipped since they are not part of the release.
+func ToKusionResources(manifests map[string]string, namespace string) (v1.Resources, error) {
+	var resources v1.Resources
+	for _, name := range sortedNames(manifests) {
+		objs, err := kubernetes.ParseManifests([]byte(manifests[name]), &kubernetes.ManifestOptions{
+			Namespace:   namespace,
+			UnfoldLists: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("parse manifest %s failed: %w", name, err)
+		}
+		for _, u := range objs {
+			if isTestHook(u) {
+				continue
+			}
+			r, err := kubernetes.UnstructuredToKusionResource(u)
+			if err != nil {
+				return nil, fmt.Errorf("%w in manifest %s", err, name)
+			}
+			resources = append(resources, *r)
+		}
+	}
+	return resources, nil
+}
+
+func isTestHook(u *unstructured.Unstructured) bool {
+	for _, hook := range strings.Split(u.GetAnnotations()[AnnotationHook], ",") {
+		if strings.HasPrefix(strings.TrimSpace(hook), "test") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"math"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ToUnstructured converts the typed Kubernetes object into unstructured content the way the Kusion
+// engine decodes it from the YAML of the resource: integers, and floats without a fractional part,
+// are int. Fields populated by the server are removed, i.e. the "creationTimestamp: null" in the
+// metadata and the empty status.
+func ToUnstructured(obj interface{}) (map[string]interface{}, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := u["status"]; ok && isEmpty(status) {
+		delete(u, "status")
+	}
+	return normalize(u).(map[string]interface{}), nil
+}
+
+// FromUnstructured converts the unstructured content into the typed Kubernetes object. Unlike
+// runtime.DefaultUnstructuredConverter, the content may contain int values, e.g. the content
+// returned by ToUnstructured or decoded from YAML. The content is not modified.
+func FromUnstructured(u map[string]interface{}, obj interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(toJSONCompatible(u).(map[string]interface{}), obj)
+}
+
+// normalize converts the numbers into int where possible and removes the null creationTimestamp of
+// the metadata, including the metadata of pod templates.
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if metadata, ok := val["metadata"].(map[string]interface{}); ok {
+			if ts, ok := metadata["creationTimestamp"]; ok && ts == nil {
+				delete(metadata, "creationTimestamp")
+			}
+		}
+		for k, item := range val {
+			val[k] = normalize(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalize(item)
+		}
+		return val
+	case int64:
+		return int(val)
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			return int(val)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// toJSONCompatible returns a copy of the value with the int values converted into int64, which is
+// required by runtime.DefaultUnstructuredConverter.
+func toJSONCompatible(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = toJSONCompatible(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = toJSONCompatible(item)
+		}
+		return out
+	case int:
+		return int64(val)
+	case int32:
+		return int64(val)
+	default:
+		return val
+	}
+}
+
+// isEmpty returns whether the value is nil or a map containing only empty values, e.g. the status
+// of a Service which is never applied: {"loadBalancer": {}}.
+func isEmpty(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		for _, item := range val {
+			if !isEmpty(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
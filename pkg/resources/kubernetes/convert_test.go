@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestToUnstructured(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "nginx"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "nginx",
+						Image: "nginx",
+						Ports: []corev1.ContainerPort{{ContainerPort: 80}},
+					}},
+				},
+			},
+		},
+	}
+
+	u, err := ToUnstructured(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "nginx"},
+		"spec": map[string]interface{}{
+			"replicas": 2,
+			"selector": nil,
+			"strategy": map[string]interface{}{},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "nginx",
+							"image":     "nginx",
+							"ports":     []interface{}{map[string]interface{}{"containerPort": 80}},
+							"resources": map[string]interface{}{},
+						},
+					},
+				},
+			},
+		},
+	}, u)
+
+	// round trip
+	got := &appsv1.Deployment{}
+	require.NoError(t, FromUnstructured(u, got))
+	assert.Equal(t, deployment, got)
+	assert.Equal(t, 2, u["spec"].(map[string]interface{})["replicas"])
+}
+
+func TestToUnstructuredKeepsStatus(t *testing.T) {
+	svc := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt32(8080)}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "1.1.1.1"}}},
+		},
+	}
+
+	u, err := ToUnstructured(svc)
+	require.NoError(t, err)
+	assert.Contains(t, u, "status")
+	ports := u["spec"].(map[string]interface{})["ports"].([]interface{})
+	assert.Equal(t, 8080, ports[0].(map[string]interface{})["targetPort"])
+
+	got := &corev1.Service{}
+	require.NoError(t, FromUnstructured(u, got))
+	assert.Equal(t, svc, got)
+}
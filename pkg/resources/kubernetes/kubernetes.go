@@ -15,6 +15,10 @@
 package kubernetes
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -23,6 +27,78 @@ import (
 	"kusionstack.io/kusion-module-framework/pkg/resources"
 )
 
+// ErrInvalidResourceID means the Kusion resource ID is not in the format
+// "<apiVersion>:<kind>[:<namespace>]:<name>".
+var ErrInvalidResourceID = errors.New(`invalid Kubernetes resource id, must be in the format "apiVersion:kind[:namespace]:name"`)
+
+// ResourceID is the parsed Kusion resource ID of a Kubernetes object.
+type ResourceID struct {
+	GroupVersionKind schema.GroupVersionKind
+	// Namespace is empty for the cluster scoped objects.
+	Namespace string
+	Name      string
+}
+
+// String returns the Kusion resource ID.
+func (id ResourceID) String() string {
+	return ToKusionResourceID(id.GroupVersionKind, metav1.ObjectMeta{Namespace: id.Namespace, Name: id.Name})
+}
+
+// ParseResourceID parses the Kusion resource ID built by ToKusionResourceID, e.g.
+// "apps/v1:Deployment:nginx:nginx-deployment", "v1:Service:nginx:nginx" and "v1:Namespace:nginx".
+// The names may contain ':', e.g. the RBAC objects "system:aggregate-to-admin", so the built-in
+// cluster scoped kinds reported by IsClusterScoped have no namespace, and the others have one if
+// there are more than three segments. Use ParseResourceIDWithScope for the cluster scoped custom
+// resources.
+func ParseResourceID(id string) (ResourceID, error) {
+	ret, rest, err := parseResourceIDPrefix(id)
+	if err != nil {
+		return ret, err
+	}
+	clusterScoped := IsClusterScoped(ret.GroupVersionKind.GroupKind()) || !strings.Contains(rest, resources.SegmentSeparator)
+	return parseResourceIDName(ret, id, rest, clusterScoped)
+}
+
+// ParseResourceIDWithScope parses the Kusion resource ID of a Kubernetes object of which the scope
+// is known, the whole remainder after the kind is the name of a cluster scoped object.
+func ParseResourceIDWithScope(id string, clusterScoped bool) (ResourceID, error) {
+	ret, rest, err := parseResourceIDPrefix(id)
+	if err != nil {
+		return ret, err
+	}
+	return parseResourceIDName(ret, id, rest, clusterScoped)
+}
+
+// parseResourceIDPrefix parses the apiVersion and the kind, and returns the remainder of the ID.
+func parseResourceIDPrefix(id string) (ResourceID, string, error) {
+	var ret ResourceID
+	parts := strings.SplitN(id, resources.SegmentSeparator, 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return ret, "", fmt.Errorf("%w: %s", ErrInvalidResourceID, id)
+	}
+	gv, err := schema.ParseGroupVersion(parts[0])
+	if err != nil || gv.Version == "" {
+		return ret, "", fmt.Errorf("%w: invalid apiVersion %s in %s", ErrInvalidResourceID, parts[0], id)
+	}
+	ret.GroupVersionKind = gv.WithKind(parts[1])
+	return ret, parts[2], nil
+}
+
+func parseResourceIDName(ret ResourceID, id, rest string, clusterScoped bool) (ResourceID, error) {
+	if !clusterScoped {
+		namespace, name, found := strings.Cut(rest, resources.SegmentSeparator)
+		if !found || namespace == "" {
+			return ret, fmt.Errorf("%w: no namespace in %s", ErrInvalidResourceID, id)
+		}
+		ret.Namespace, rest = namespace, name
+	}
+	if rest == "" {
+		return ret, fmt.Errorf("%w: %s", ErrInvalidResourceID, id)
+	}
+	ret.Name = rest
+	return ret, nil
+}
+
 // ToKusionResourceID returns the Kusion resource ID for the given Kubernetes object specified by
 // its GroupVersionKind and ObjectMeta.
 func ToKusionResourceID(gvk schema.GroupVersionKind, objectMeta metav1.ObjectMeta) string {
@@ -38,8 +114,7 @@ func ToKusionResourceID(gvk schema.GroupVersionKind, objectMeta metav1.ObjectMet
 
 // NewKusionResource creates a Kusion Resource object with the given obj and objectMeta.
 func NewKusionResource(obj runtime.Object, objectMeta metav1.ObjectMeta) (*v1.Resource, error) {
-	// TODO: this function converts int to int64 by default
-	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	unstructured, err := ToUnstructured(obj)
 	if err != nil {
 		return nil, err
 	}
@@ -55,3 +130,35 @@ func NewKusionResource(obj runtime.Object, objectMeta metav1.ObjectMeta) (*v1.Re
 		},
 	}, nil
 }
+
+// clusterScopedKinds are the built-in Kubernetes kinds which are not namespaced.
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Namespace"}:                                                    true,
+	{Group: "", Kind: "Node"}:                                                         true,
+	{Group: "", Kind: "PersistentVolume"}:                                             true,
+	{Group: "", Kind: "ComponentStatus"}:                                              true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                         true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                  true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:                 true,
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                             true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:     true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                   true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                      true,
+	{Group: "storage.k8s.io", Kind: "CSINode"}:                                        true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                               true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                               true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                      true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                                true,
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:                 true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                       true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:       true,
+}
+
+// IsClusterScoped returns whether the objects of the built-in Kubernetes kind are cluster scoped.
+// The scope of custom resources is unknown here, so they are regarded as namespaced.
+func IsClusterScoped(gk schema.GroupKind) bool {
+	return clusterScopedKinds[gk]
+}
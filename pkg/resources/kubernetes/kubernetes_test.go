@@ -0,0 +1,112 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected ResourceID
+		wantErr  bool
+	}{
+		{
+			id: "apps/v1:Deployment:nginx:nginx-deployment",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				Namespace:        "nginx",
+				Name:             "nginx-deployment",
+			},
+		},
+		{
+			id: "v1:Service:nginx:nginx",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+				Namespace:        "nginx",
+				Name:             "nginx",
+			},
+		},
+		{
+			id: "rbac.authorization.k8s.io/v1:ClusterRole:admin",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+				Name:             "admin",
+			},
+		},
+		{
+			id: "rbac.authorization.k8s.io/v1:ClusterRole:system:aggregate-to-admin",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+				Name:             "system:aggregate-to-admin",
+			},
+		},
+		{
+			id: "rbac.authorization.k8s.io/v1:ClusterRoleBinding:system:controller:bootstrap-signer",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+				Name:             "system:controller:bootstrap-signer",
+			},
+		},
+		{
+			id: "rbac.authorization.k8s.io/v1:RoleBinding:kube-system:system:controller:bootstrap-signer",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+				Namespace:        "kube-system",
+				Name:             "system:controller:bootstrap-signer",
+			},
+		},
+		{
+			id: "example.com/v1:Widget:foo",
+			expected: ResourceID{
+				GroupVersionKind: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+				Name:             "foo",
+			},
+		},
+		{id: "v1:Namespace", wantErr: true},
+		{id: "v1:Namespace:", wantErr: true},
+		{id: "v1:Service::nginx", wantErr: true},
+		{id: "v1:Service:nginx:", wantErr: true},
+		{id: "a/b/c:Service:nginx", wantErr: true},
+		{id: ":Service:nginx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, err := ParseResourceID(tt.id)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidResourceID)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.id, got.String())
+		})
+	}
+}
+
+func TestParseResourceIDWithScope(t *testing.T) {
+	got, err := ParseResourceIDWithScope("example.com/v1:ClusterWidget:system:foo", true)
+	require.NoError(t, err)
+	assert.Equal(t, ResourceID{
+		GroupVersionKind: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "ClusterWidget"},
+		Name:             "system:foo",
+	}, got)
+
+	got, err = ParseResourceIDWithScope("example.com/v1:Widget:system:foo", false)
+	require.NoError(t, err)
+	assert.Equal(t, "system", got.Namespace)
+	assert.Equal(t, "foo", got.Name)
+
+	_, err = ParseResourceIDWithScope("example.com/v1:Widget:foo", false)
+	assert.ErrorIs(t, err, ErrInvalidResourceID)
+}
+
+func TestIsClusterScoped(t *testing.T) {
+	assert.True(t, IsClusterScoped(schema.GroupKind{Kind: "Namespace"}))
+	assert.True(t, IsClusterScoped(schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}))
+	assert.False(t, IsClusterScoped(schema.GroupKind{Group: "apps", Kind: "Deployment"}))
+	assert.False(t, IsClusterScoped(schema.GroupKind{Group: "example.com", Kind: "Namespace"}))
+}
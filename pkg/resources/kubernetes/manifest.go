@@ -0,0 +1,159 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// ErrListKind means the manifests contain a List kind, e.g. a v1/List, which is rejected unless
+// ManifestOptions.UnfoldLists is set.
+var ErrListKind = errors.New("list kinds are not supported")
+
+// ManifestOptions are the options to parse Kubernetes manifests.
+type ManifestOptions struct {
+	// Namespace is the namespace of the namespaced objects without a namespace, usually the
+	// application name in the GeneratorRequest. The objects are left as they are if it is empty.
+	Namespace string
+	// UnfoldLists replaces the List kinds with their items, otherwise they are rejected.
+	UnfoldLists bool
+}
+
+// ParseManifests parses the multi-document YAML, or the JSON stream, of Kubernetes manifests into
+// unstructured objects in order, the empty documents are skipped. The namespaced objects without a
+// namespace are put in opts.Namespace. The custom resources are namespaced unless a cluster scoped
+// CustomResourceDefinition of them is in the manifests.
+func ParseManifests(data []byte, opts *ManifestOptions) ([]*unstructured.Unstructured, error) {
+	if opts == nil {
+		opts = &ManifestOptions{}
+	}
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for i := 0; ; i++ {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode document %d failed: %w", i, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: obj}
+		if !u.IsList() {
+			if err := validateObject(u); err != nil {
+				return nil, fmt.Errorf("invalid document %d: %w", i, err)
+			}
+			objs = append(objs, u)
+			continue
+		}
+		if !opts.UnfoldLists {
+			return nil, fmt.Errorf("%w: document %d is a %s", ErrListKind, i, u.GetKind())
+		}
+		items, _ := obj["items"].([]interface{})
+		for j, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid item %d of document %d: not an object", j, i)
+			}
+			itemObj := &unstructured.Unstructured{Object: m}
+			if err := validateObject(itemObj); err != nil {
+				return nil, fmt.Errorf("invalid item %d of document %d: %w", j, i, err)
+			}
+			objs = append(objs, itemObj)
+		}
+	}
+
+	if opts.Namespace != "" {
+		clusterScoped := clusterScopedCustomKinds(objs)
+		for _, u := range objs {
+			gk := u.GroupVersionKind().GroupKind()
+			if u.GetNamespace() == "" && !IsClusterScoped(gk) && !clusterScoped[gk] {
+				u.SetNamespace(opts.Namespace)
+			}
+		}
+	}
+	return objs, nil
+}
+
+// ManifestsToKusionResources parses the Kubernetes manifests by ParseManifests and converts the
+// objects into Kusion resources.
+func ManifestsToKusionResources(data []byte, opts *ManifestOptions) (v1.Resources, error) {
+	objs, err := ParseManifests(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	resources := make(v1.Resources, 0, len(objs))
+	for _, u := range objs {
+		r, err := UnstructuredToKusionResource(u)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *r)
+	}
+	return resources, nil
+}
+
+// UnstructuredToKusionResource converts the unstructured object into a Kusion resource by
+// NewKusionResource.
+func UnstructuredToKusionResource(u *unstructured.Unstructured) (*v1.Resource, error) {
+	r, err := NewKusionResource(u, metav1.ObjectMeta{Namespace: u.GetNamespace(), Name: u.GetName()})
+	if err != nil {
+		return nil, fmt.Errorf("convert %s %s failed: %w", u.GetKind(), u.GetName(), err)
+	}
+	return r, nil
+}
+
+func validateObject(u *unstructured.Unstructured) error {
+	gvk := u.GroupVersionKind()
+	switch {
+	case gvk.Version == "":
+		return errors.New("missing apiVersion")
+	case gvk.Kind == "":
+		return errors.New("missing kind")
+	case u.GetName() == "":
+		return fmt.Errorf("missing metadata.name of %s", gvk.Kind)
+	}
+	return nil
+}
+
+// clusterScopedCustomKinds returns the custom kinds defined as cluster scoped by the
+// CustomResourceDefinitions in the objects.
+func clusterScopedCustomKinds(objs []*unstructured.Unstructured) map[schema.GroupKind]bool {
+	kinds := map[schema.GroupKind]bool{}
+	for _, u := range objs {
+		if u.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}) {
+			continue
+		}
+		scope, _, _ := unstructured.NestedString(u.Object, "spec", "scope")
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+		if scope == "Cluster" {
+			kinds[schema.GroupKind{Group: group, Kind: kind}] = true
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+const manifests = `# the CRD of a cluster scoped custom resource
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterissuers.cert-manager.io
+spec:
+  group: cert-manager.io
+  scope: Cluster
+  names:
+    kind: ClusterIssuer
+---
+---
+apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: operator
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: operator
+  namespace: kube-system
+roleRef:
+  kind: ClusterRole
+  name: view
+`
+
+func TestParseManifests(t *testing.T) {
+	objs, err := ParseManifests([]byte(manifests), &ManifestOptions{Namespace: "foo"})
+	require.NoError(t, err)
+	require.Len(t, objs, 4)
+
+	var namespaces []string
+	for _, u := range objs {
+		namespaces = append(namespaces, u.GetNamespace())
+	}
+	assert.Equal(t, []string{"", "", "foo", "kube-system"}, namespaces)
+
+	objs, err = ParseManifests([]byte(manifests), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", objs[2].GetNamespace())
+}
+
+func TestParseManifestsJSON(t *testing.T) {
+	data := `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}, "data": {"replicas": "1"}}
+{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "b"}},
+  {"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "c"}}
+]}`
+
+	_, err := ParseManifests([]byte(data), &ManifestOptions{Namespace: "foo"})
+	assert.ErrorIs(t, err, ErrListKind)
+
+	objs, err := ParseManifests([]byte(data), &ManifestOptions{Namespace: "foo", UnfoldLists: true})
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+	assert.Equal(t, "Secret", objs[1].GetKind())
+	assert.Equal(t, "foo", objs[1].GetNamespace())
+	assert.Equal(t, "", objs[2].GetNamespace())
+}
+
+func TestParseManifestsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "missing apiVersion", data: "kind: Service\nmetadata:\n  name: a\n", err: "invalid document 0: missing apiVersion"},
+		{name: "missing kind", data: "apiVersion: v1\nmetadata:\n  name: a\n", err: "invalid document 0: missing kind"},
+		{name: "missing name", data: "---\napiVersion: v1\nkind: Service\n---\napiVersion: v1\nkind: Service\n", err: "invalid document 0: missing metadata.name of Service"},
+		{name: "invalid list item", data: "apiVersion: v1\nkind: List\nitems:\n  - foo\n", err: "invalid item 0 of document 0: not an object"},
+		{name: "invalid yaml", data: "apiVersion: v1\nkind: [", err: "decode document 0 failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseManifests([]byte(tt.data), &ManifestOptions{UnfoldLists: true})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestManifestsToKusionResources(t *testing.T) {
+	resources, err := ManifestsToKusionResources([]byte(manifests), &ManifestOptions{Namespace: "foo"})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []string{
+		"apiextensions.k8s.io/v1:CustomResourceDefinition:clusterissuers.cert-manager.io",
+		"cert-manager.io/v1:ClusterIssuer:letsencrypt",
+		"v1:ServiceAccount:foo:operator",
+		"rbac.authorization.k8s.io/v1:RoleBinding:kube-system:operator",
+	}, ids)
+	assert.Equal(t, "cert-manager.io/v1, Kind=ClusterIssuer", resources[1].Extensions[v1.ResourceExtensionGVK])
+	assert.Equal(t, "foo", resources[2].Attributes["metadata"].(map[string]any)["namespace"])
+}
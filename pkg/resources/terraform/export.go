@@ -0,0 +1,323 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/graph"
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+)
+
+// ErrExport means the resources can't be exported as a Terraform configuration.
+var ErrExport = errors.New("export Terraform configuration failed")
+
+// Config is a Terraform configuration in the JSON syntax.
+type Config struct {
+	Terraform *TerraformBlock `json:"terraform,omitempty"`
+	// Provider are the provider blocks by the provider local names.
+	Provider map[string][]map[string]any `json:"provider,omitempty"`
+	// Resource are the resource blocks by the resource types and names.
+	Resource map[string]map[string]map[string]any `json:"resource,omitempty"`
+	Import   []ImportBlock                        `json:"import,omitempty"`
+}
+
+// TerraformBlock is the terraform block of a Terraform configuration.
+type TerraformBlock struct {
+	RequiredProviders map[string]RequiredProvider `json:"required_providers"`
+}
+
+// RequiredProvider is a provider requirement in the required_providers block.
+type RequiredProvider struct {
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+}
+
+// ImportBlock imports an existing resource into the Terraform state.
+type ImportBlock struct {
+	To string `json:"to"`
+	ID string `json:"id"`
+}
+
+// invalidNameChars are the characters not allowed in Terraform resource names.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// exported is a Terraform resource to export.
+type exported struct {
+	resource *v1.Resource
+	source   string
+	version  string
+	typ      string
+	name     string
+	alias    string
+}
+
+// address returns the address of the resource in the Terraform configuration.
+func (e *exported) address() string {
+	return e.typ + "." + e.name
+}
+
+// Export exports the Terraform resources as a standalone Terraform configuration, the resources of
+// other types are skipped:
+//   - the required_providers block is built from the provider extensions, the version constraints
+//     of the same provider are intersected, and the incompatible ones are ErrIncompatibleVersions
+//   - the provider blocks are built from the providerMeta extensions, the resources with different
+//     providerMeta of the same provider use the provider aliases
+//   - the resource blocks are built from the attributes, named after the resource names in the IDs
+//   - the dependsOn on the exported resources are the depends_on of the resources
+//   - the "$kusion_path" references to the exported resources are the Terraform references
+//   - the import blocks are built from the kusionstack.io/import-id extensions
+func Export(rs v1.Resources) (*Config, error) {
+	var list []*exported
+	byID := map[string]*exported{}
+	addresses := map[string]string{}
+	for i := range rs {
+		r := &rs[i]
+		if r.Type != v1.Terraform {
+			continue
+		}
+		e, err := newExported(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byID[r.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicate resource %s", ErrExport, r.ID)
+		}
+		if id, ok := addresses[e.address()]; ok {
+			return nil, fmt.Errorf("%w: resources %s and %s have the same address %s", ErrExport, id, r.ID, e.address())
+		}
+		byID[r.ID] = e
+		addresses[e.address()] = r.ID
+		list = append(list, e)
+	}
+	if len(list) == 0 {
+		return &Config{}, nil
+	}
+
+	c := &Config{
+		Terraform: &TerraformBlock{RequiredProviders: map[string]RequiredProvider{}},
+		Provider:  map[string][]map[string]any{},
+		Resource:  map[string]map[string]map[string]any{},
+	}
+	if err := c.addProviders(list); err != nil {
+		return nil, err
+	}
+
+	exists := func(id string) bool { _, ok := byID[id]; return ok }
+	for _, e := range list {
+		block := map[string]any{}
+		for k, v := range e.resource.Attributes {
+			block[k] = toExpression(v, byID, exists)
+		}
+		var dependsOn []string
+		for _, dep := range e.resource.DependsOn {
+			if d, ok := byID[dep]; ok {
+				dependsOn = append(dependsOn, d.address())
+			}
+		}
+		if len(dependsOn) != 0 {
+			block["depends_on"] = dependsOn
+		}
+		if e.alias != "" {
+			block["provider"] = providerLocalName(e.source) + "." + e.alias
+		}
+		if c.Resource[e.typ] == nil {
+			c.Resource[e.typ] = map[string]map[string]any{}
+		}
+		c.Resource[e.typ][e.name] = block
+
+		if importID, ok := e.resource.Extensions[resources.ExtensionImportID].(string); ok && importID != "" {
+			c.Import = append(c.Import, ImportBlock{To: e.address(), ID: importID})
+		}
+	}
+	return c, nil
+}
+
+// ExportJSON exports the Terraform resources by Export, and returns the indented JSON of the
+// configuration, which can be saved as a "main.tf.json" file.
+func ExportJSON(rs v1.Resources) ([]byte, error) {
+	c, err := Export(rs)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(c, "", "  ")
+}
+
+func newExported(r *v1.Resource) (*exported, error) {
+	provider, _ := r.Extensions[resources.ExtensionProvider].(string)
+	source, version, ok := splitProviderExtension(provider)
+	if !ok {
+		return nil, fmt.Errorf("%w: resource %s has an invalid %s extension %q", ErrExport, r.ID, resources.ExtensionProvider, provider)
+	}
+	if _, err := parseProviderSourceString(source); err != nil {
+		return nil, fmt.Errorf("%w: resource %s: %v", ErrExport, r.ID, err)
+	}
+	if _, err := ParseVersionConstraint(version); err != nil {
+		return nil, fmt.Errorf("%w: resource %s: %w", ErrExport, r.ID, err)
+	}
+	typ, _ := r.Extensions[resources.ExtensionResourceType].(string)
+	if typ == "" {
+		return nil, fmt.Errorf("%w: resource %s has no %s extension", ErrExport, r.ID, resources.ExtensionResourceType)
+	}
+
+	name := r.ID
+	if id, err := ParseResourceID(r.ID); err == nil {
+		name = id.ResourceName
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty resource id", ErrExport)
+	}
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "_" + name
+	}
+	return &exported{resource: r, source: source, version: version, typ: typ, name: name}, nil
+}
+
+// addProviders adds the required providers and the provider blocks of the resources, and sets the
+// provider aliases of the resources.
+func (c *Config) addProviders(list []*exported) error {
+	type providerConfig struct {
+		meta  map[string]any
+		alias string
+	}
+	configs := map[string][]*providerConfig{}
+	for _, e := range list {
+		localName := providerLocalName(e.source)
+		required, ok := c.Terraform.RequiredProviders[localName]
+		switch {
+		case !ok:
+			c.Terraform.RequiredProviders[localName] = RequiredProvider{Source: e.source, Version: e.version}
+		case !sameSource(required.Source, e.source):
+			return fmt.Errorf("%w: providers %s and %s have the same local name %s", ErrExport, required.Source, e.source, localName)
+		case required.Version != e.version:
+			merged, err := intersectVersions(required.Version, e.version)
+			if err != nil {
+				return fmt.Errorf("%w: provider %s: %w", ErrExport, e.source, err)
+			}
+			required.Version = merged
+			c.Terraform.RequiredProviders[localName] = required
+		}
+
+		meta, _ := e.resource.Extensions[resources.ExtensionProviderMeta].(map[string]any)
+		if m, ok := e.resource.Extensions[resources.ExtensionProviderMeta].(v1.GenericConfig); ok {
+			meta = m
+		}
+		var config *providerConfig
+		for _, pc := range configs[localName] {
+			if reflect.DeepEqual(pc.meta, meta) || len(pc.meta) == 0 && len(meta) == 0 {
+				config = pc
+				break
+			}
+		}
+		if config == nil {
+			config = &providerConfig{meta: meta}
+			if n := len(configs[localName]); n > 0 {
+				config.alias = fmt.Sprintf("%s_%d", localName, n+1)
+			}
+			configs[localName] = append(configs[localName], config)
+		}
+		e.alias = config.alias
+	}
+
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, pc := range configs[name] {
+			block := make(map[string]any, len(pc.meta)+1)
+			for k, v := range pc.meta {
+				block[k] = v
+			}
+			if pc.alias != "" {
+				block["alias"] = pc.alias
+			}
+			c.Provider[name] = append(c.Provider[name], block)
+		}
+	}
+	return nil
+}
+
+// intersectVersions returns the version constraint satisfying both version constraints.
+func intersectVersions(a, b string) (string, error) {
+	ca, err := ParseVersionConstraint(a)
+	if err != nil {
+		return "", err
+	}
+	cb, err := ParseVersionConstraint(b)
+	if err != nil {
+		return "", err
+	}
+	merged, err := ca.Intersect(cb)
+	if err != nil {
+		return "", err
+	}
+	return merged.String(), nil
+}
+
+// providerLocalName returns the local name of the provider, which is the provider type.
+func providerLocalName(source string) string {
+	return source[strings.LastIndex(source, "/")+1:]
+}
+
+func sameSource(a, b string) bool {
+	pa, errA := parseProviderSourceString(a)
+	pb, errB := parseProviderSourceString(b)
+	return errA == nil && errB == nil && pa == pb
+}
+
+// toExpression converts the attribute value into a Terraform JSON expression. The "$kusion_path"
+// references to the exported resources are converted into Terraform references, and the template
+// sequences in other strings are escaped.
+func toExpression(v any, byID map[string]*exported, exists func(string) bool) any {
+	switch val := v.(type) {
+	case string:
+		if id, ok := graph.ResolveReference(val, exists); ok {
+			attr := strings.TrimPrefix(val, graph.KusionPathPrefix+id+".")
+			return "${" + byID[id].address() + "." + attr + "}"
+		}
+		return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = toExpression(item, byID, exists)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = toExpression(item, byID, exists)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = toExpression(item, byID, exists)
+		}
+		return out
+	default:
+		return val
+	}
+}
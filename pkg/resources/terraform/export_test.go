@@ -0,0 +1,158 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+)
+
+func newResource(t *testing.T, p Provider, resourceType, name string, attrs map[string]any, dependsOn ...string) v1.Resource {
+	id, err := ToKusionResourceID(p, resourceType, name)
+	require.NoError(t, err)
+	r, err := NewKusionResource(p, resourceType, id, attrs, dependsOn)
+	require.NoError(t, err)
+	return *r
+}
+
+func TestExportJSON(t *testing.T) {
+	aws, err := NewProvider(map[string]any{"region": "us-east-1"}, "registry.terraform.io/hashicorp/aws", "5.0.1")
+	require.NoError(t, err)
+	awsWest := aws
+	awsWest.ProviderConfigs = map[string]any{"region": "us-west-2"}
+	random, err := NewProvider(nil, "registry.terraform.io/hashicorp/random", "3.6.0")
+	require.NoError(t, err)
+
+	password := newResource(t, random, "random_password", "mysql", map[string]any{"length": 16})
+	db := newResource(t, aws, "aws_db_instance", "mysql", map[string]any{
+		"password": "$kusion_path." + password.ID + ".result",
+		"tags":     map[string]any{"note": "${not a reference}"},
+	}, password.ID, "v1:Namespace:foo")
+	bucket := newResource(t, awsWest, "aws_s3_bucket", "assets.example.com", map[string]any{"bucket": "assets"})
+	bucket.Extensions[resources.ExtensionImportID] = "assets"
+	namespace := v1.Resource{ID: "v1:Namespace:foo", Type: v1.Kubernetes}
+
+	data, err := ExportJSON(v1.Resources{password, db, namespace, bucket})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "terraform": {
+    "required_providers": {
+      "aws": {"source": "registry.terraform.io/hashicorp/aws", "version": "5.0.1"},
+      "random": {"source": "registry.terraform.io/hashicorp/random", "version": "3.6.0"}
+    }
+  },
+  "provider": {
+    "aws": [{"region": "us-east-1"}, {"region": "us-west-2", "alias": "aws_2"}],
+    "random": [{}]
+  },
+  "resource": {
+    "random_password": {"mysql": {"length": 16}},
+    "aws_db_instance": {
+      "mysql": {
+        "password": "${random_password.mysql.result}",
+        "tags": {"note": "$${not a reference}"},
+        "depends_on": ["random_password.mysql"]
+      }
+    },
+    "aws_s3_bucket": {
+      "assets_example_com": {"bucket": "assets", "provider": "aws.aws_2"}
+    }
+  },
+  "import": [{"to": "aws_s3_bucket.assets_example_com", "id": "assets"}]
+}`, string(data))
+
+	data, err = ExportJSON(v1.Resources{namespace})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestExportErrors(t *testing.T) {
+	aws, err := NewProvider(nil, "hashicorp/aws", "5.0.1")
+	require.NoError(t, err)
+	aws4 := aws
+	aws4.Version = "4.67.0"
+	otherAWS, err := NewProvider(nil, "example.com/acme/aws", "1.0.0")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		resources v1.Resources
+		err       string
+	}{
+		{
+			name: "different versions",
+			resources: v1.Resources{
+				newResource(t, aws, "aws_s3_bucket", "a", nil),
+				newResource(t, aws4, "aws_s3_bucket", "b", nil),
+			},
+			err: `provider hashicorp/aws: incompatible provider version constraints "5.0.1" and "4.67.0"`,
+		},
+		{
+			name:      "malformed version",
+			resources: v1.Resources{{ID: "a", Type: v1.Terraform, Extensions: map[string]any{"provider": "hashicorp/aws/>= 4.0,, <5"}}},
+			err:       `resource a: invalid provider version constraint ">= 4.0,, <5": empty condition`,
+		},
+		{
+			name: "same local name",
+			resources: v1.Resources{
+				newResource(t, aws, "aws_s3_bucket", "a", nil),
+				newResource(t, otherAWS, "aws_s3_bucket", "b", nil),
+			},
+			err: "providers hashicorp/aws and example.com/acme/aws have the same local name aws",
+		},
+		{
+			name: "same address",
+			resources: v1.Resources{
+				newResource(t, aws, "aws_s3_bucket", "a.b", nil),
+				newResource(t, aws, "aws_s3_bucket", "a_b", nil),
+			},
+			err: "have the same address aws_s3_bucket.a_b",
+		},
+		{
+			name:      "missing provider",
+			resources: v1.Resources{{ID: "a", Type: v1.Terraform, Extensions: map[string]any{}}},
+			err:       `resource a has an invalid provider extension ""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Export(tt.resources)
+			assert.ErrorIs(t, err, ErrExport)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestExportVersionConstraints(t *testing.T) {
+	aws, err := NewProvider(nil, "hashicorp/aws", "~> 5.0")
+	require.NoError(t, err)
+	aws52 := aws
+	aws52.Version = ">=5.2"
+
+	c, err := Export(v1.Resources{
+		newResource(t, aws, "aws_s3_bucket", "a", nil),
+		newResource(t, aws52, "aws_s3_bucket", "b", nil),
+		newResource(t, aws, "aws_s3_bucket", "c", nil),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]RequiredProvider{
+		"aws": {Source: "hashicorp/aws", Version: "~> 5.0, >= 5.2"},
+	}, c.Terraform.RequiredProviders)
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	aws, err := NewProvider(nil, "hashicorp/aws", "5.0.1")
+	require.NoError(t, err)
+	c, err := Export(v1.Resources{newResource(t, aws, "aws_s3_bucket", "a", map[string]any{"bucket": "a"})})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(c)
+	require.NoError(t, err)
+	var decoded Config
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, c, &decoded)
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// ErrNoMatchingVersion means no version of the provider in the mirror satisfies the version constraint.
+var ErrNoMatchingVersion = errors.New("no matching provider version")
+
+// Mirror is a local provider mirror directory, e.g. populated by "terraform providers mirror", in
+// either of the layouts of the Terraform filesystem mirrors:
+//   - unpacked: HOSTNAME/NAMESPACE/TYPE/VERSION/TARGET/
+//   - packed: HOSTNAME/NAMESPACE/TYPE/terraform-provider-TYPE_VERSION_TARGET.zip
+type Mirror struct {
+	// Dir is the mirror directory.
+	Dir string
+	// Platform is the TARGET of the providers, e.g. "linux_amd64", the platform of the running
+	// program is used if it is empty.
+	Platform string
+}
+
+// NewMirror returns the Mirror of the directory for the platform of the running program.
+func NewMirror(dir string) *Mirror {
+	return &Mirror{Dir: dir}
+}
+
+// Versions returns the versions of the provider available in the mirror for the platform, in
+// ascending order.
+func (m *Mirror) Versions(source string) ([]*version.Version, error) {
+	tp, err := parseProviderSourceString(source)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(m.Dir, tp.Hostname.ForDisplay(), tp.Namespace, tp.Type)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read provider mirror failed: %w", err)
+	}
+
+	platform := m.platform()
+	packedPrefix := "terraform-provider-" + tp.Type + "_"
+	packedSuffix := "_" + platform + ".zip"
+	var versions []*version.Version
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			if info, err := os.Stat(filepath.Join(dir, name, platform)); err != nil || !info.IsDir() {
+				continue
+			}
+		case strings.HasPrefix(name, packedPrefix) && strings.HasSuffix(name, packedSuffix):
+			name = strings.TrimSuffix(strings.TrimPrefix(name, packedPrefix), packedSuffix)
+		default:
+			continue
+		}
+		v, err := version.NewVersion(name)
+		if err != nil || seen[v.String()] {
+			continue
+		}
+		seen[v.String()] = true
+		versions = append(versions, v)
+	}
+	sort.Sort(version.Collection(versions))
+	return versions, nil
+}
+
+// Resolve returns the provider with the version pinned to the newest version in the mirror
+// satisfying its version constraint, or ErrNoMatchingVersion if there is none.
+func (m *Mirror) Resolve(p Provider) (Provider, error) {
+	constraint, err := ParseVersionConstraint(p.Version)
+	if err != nil {
+		return p, err
+	}
+	versions, err := m.Versions(p.Source)
+	if err != nil {
+		return p, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if constraint.Check(versions[i]) {
+			p.Version = versions[i].Original()
+			return p, nil
+		}
+	}
+	return p, fmt.Errorf("%w: provider %s %q for %s in %s", ErrNoMatchingVersion, p.Source, p.Version, m.platform(), m.Dir)
+}
+
+func (m *Mirror) platform() string {
+	if m.Platform != "" {
+		return m.Platform
+	}
+	return runtime.GOOS + "_" + runtime.GOARCH
+}
@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirror(t *testing.T) {
+	dir := t.TempDir()
+	aws := filepath.Join(dir, "registry.terraform.io", "hashicorp", "aws")
+	for _, p := range []string{"4.67.0/linux_amd64", "5.1.0/linux_amd64", "5.2.0/darwin_arm64", "5.3.0-beta1/linux_amd64", "latest/linux_amd64"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(aws, p), 0o755))
+	}
+	for _, name := range []string{
+		"terraform-provider-aws_5.1.0_linux_amd64.zip",
+		"terraform-provider-aws_5.2.1_linux_amd64.zip",
+		"terraform-provider-aws_5.4.0_darwin_arm64.zip",
+		"terraform-provider-aws_5.4.0.json",
+		"index.json",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(aws, name), nil, 0o644))
+	}
+
+	m := &Mirror{Dir: dir, Platform: "linux_amd64"}
+	versions, err := m.Versions("hashicorp/aws")
+	require.NoError(t, err)
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.Original())
+	}
+	assert.Equal(t, []string{"4.67.0", "5.1.0", "5.2.1", "5.3.0-beta1"}, got)
+
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{constraint: "~> 5.0", expected: "5.2.1"},
+		{constraint: "~> 5.1.0", expected: "5.1.0"},
+		{constraint: ">= 4.0, < 5.0", expected: "4.67.0"},
+		{constraint: "5.3.0-beta1", expected: "5.3.0-beta1"},
+	}
+	for _, tt := range tests {
+		p, err := NewProvider(map[string]any{"region": "us-east-1"}, "registry.terraform.io/hashicorp/aws", tt.constraint)
+		require.NoError(t, err)
+		resolved, err := m.Resolve(p)
+		require.NoError(t, err, tt.constraint)
+		assert.Equal(t, tt.expected, resolved.Version, tt.constraint)
+		assert.Equal(t, p.Source, resolved.Source)
+		assert.Equal(t, p.ProviderConfigs, resolved.ProviderConfigs)
+	}
+
+	_, err = m.Resolve(Provider{Source: "hashicorp/aws", Version: "~> 5.4"})
+	assert.ErrorIs(t, err, ErrNoMatchingVersion)
+	_, err = m.Resolve(Provider{Source: "hashicorp/random", Version: "3.6.0"})
+	assert.ErrorIs(t, err, ErrNoMatchingVersion)
+	_, err = m.Resolve(Provider{Source: "hashicorp/aws", Version: "latest"})
+	assert.ErrorIs(t, err, errInvalidVersion)
+
+	versions, err = NewMirror(dir).Versions("hashicorp/random")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
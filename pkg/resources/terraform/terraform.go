@@ -32,10 +32,14 @@ const DefaultProviderRegistryHost = "registry.terraform.io"
 var (
 	// errInvalidSource means provider's source is invalid, which must be in [<HOSTNAME>/]<NAMESPACE>/<TYPE> format
 	errInvalidSource = errors.New(`invalid provider source string, must be in the format "[hostname/]namespace/name"`)
-	// errInvalidVersion means provider's version constraint is invalid, which must not be empty.
+	// errInvalidVersion means provider's version constraint is invalid, which must be a non-empty
+	// constraint parsed by ParseVersionConstraint.
 	errInvalidVersion = errors.New("invalid provider version constraint")
 	// errInvalidResourceTypeOrName resourceType or resourceName is invalid, which must not be empty.
 	errInvalidResourceTypeOrName = errors.New("resourceType or resourceName is empty")
+	// ErrInvalidResourceID means the Kusion resource ID is not in the format
+	// "<providerNamespace>:<providerType>:<resourceType>:<resourceName>".
+	ErrInvalidResourceID = errors.New(`invalid Terraform resource id, must be in the format "providerNamespace:providerType:resourceType:resourceName"`)
 )
 
 // NewProvider constructs a provider instance from given source, version and configuration arguments.
@@ -45,6 +49,9 @@ func NewProvider(providerConfigs map[string]any, source, version string) (Provid
 	if version == "" {
 		return ret, errInvalidVersion
 	}
+	if _, err := ParseVersionConstraint(version); err != nil {
+		return ret, err
+	}
 
 	_, err := parseProviderSourceString(source)
 	if err != nil {
@@ -74,6 +81,27 @@ func ToKusionResourceID(p Provider, resourceType, resourceName string) (string,
 	return strings.Join([]string{tfProviderIDStr, resourceType, resourceName}, resources.SegmentSeparator), nil
 }
 
+// ParseResourceID parses the Kusion resource ID built by ToKusionResourceID, e.g.
+// "hashicorp:aws:aws_db_instance:mysql".
+func ParseResourceID(id string) (ResourceID, error) {
+	var ret ResourceID
+	parts := strings.Split(id, resources.SegmentSeparator)
+	if len(parts) != 4 {
+		return ret, fmt.Errorf("%w: %s", ErrInvalidResourceID, id)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ret, fmt.Errorf("%w: %s", ErrInvalidResourceID, id)
+		}
+	}
+
+	ret.ProviderNamespace = parts[0]
+	ret.ProviderType = parts[1]
+	ret.ResourceType = parts[2]
+	ret.ResourceName = parts[3]
+	return ret, nil
+}
+
 // NewKusionResource creates a Kusion Resource object with the given resourceType, resourceID, attributes.
 func NewKusionResource(p Provider, resourceType, resourceID string,
 	attrs map[string]interface{}, dependsOn []string,
@@ -97,6 +125,16 @@ func NewKusionResource(p Provider, resourceType, resourceID string,
 	}, nil
 }
 
+// splitProviderExtension splits the provider extension "[hostname/]namespace/name/version" into the
+// source and the version constraint.
+func splitProviderExtension(provider string) (source, version string, ok bool) {
+	i := strings.LastIndex(provider, "/")
+	if i <= 0 || i == len(provider)-1 {
+		return "", "", false
+	}
+	return provider[:i], provider[i+1:], true
+}
+
 // parseProviderSourceString parses the source attribute and returns a terraform provider.
 //
 // The following are valid source string formats:
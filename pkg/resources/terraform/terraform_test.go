@@ -0,0 +1,30 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceID(t *testing.T) {
+	p, err := NewProvider(nil, "registry.terraform.io/hashicorp/aws", "5.0.1")
+	require.NoError(t, err)
+	id, err := ToKusionResourceID(p, "aws_db_instance", "mysql")
+	require.NoError(t, err)
+
+	got, err := ParseResourceID(id)
+	require.NoError(t, err)
+	assert.Equal(t, ResourceID{
+		ProviderNamespace: "hashicorp",
+		ProviderType:      "aws",
+		ResourceType:      "aws_db_instance",
+		ResourceName:      "mysql",
+	}, got)
+	assert.Equal(t, id, got.String())
+
+	for _, invalid := range []string{"", "hashicorp:aws:aws_db_instance", "hashicorp::aws_db_instance:mysql", "a:b:c:d:e"} {
+		_, err = ParseResourceID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidResourceID, invalid)
+	}
+}
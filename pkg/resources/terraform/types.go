@@ -15,6 +15,8 @@
 package terraform
 
 import (
+	"strings"
+
 	svchost "github.com/hashicorp/terraform-svchost"
 
 	"kusionstack.io/kusion-module-framework/pkg/resources"
@@ -53,3 +55,16 @@ func (tp TFProvider) String() string {
 func (tp TFProvider) IDString() string {
 	return tp.Namespace + resources.SegmentSeparator + tp.Type
 }
+
+// ResourceID is the parsed Kusion resource ID of a Terraform resource.
+type ResourceID struct {
+	ProviderNamespace string
+	ProviderType      string
+	ResourceType      string
+	ResourceName      string
+}
+
+// String returns the Kusion resource ID.
+func (id ResourceID) String() string {
+	return strings.Join([]string{id.ProviderNamespace, id.ProviderType, id.ResourceType, id.ResourceName}, resources.SegmentSeparator)
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+)
+
+// ErrIncompatibleVersions means no version satisfies all version constraints of a provider.
+var ErrIncompatibleVersions = errors.New("incompatible provider version constraints")
+
+// conditionRegexp matches a valid condition of a version constraint, e.g. ">= 4.0" and "~> 5.2".
+var conditionRegexp = regexp.MustCompile(`^(=|!=|>=|<=|>|<|~>)?\s*(\d\S*)$`)
+
+// VersionConstraint is a parsed provider version constraint, which is a comma-separated list of
+// conditions all satisfied by the selected version, e.g. "5.0.1", ">= 4.0, < 6.0" and "~> 5.2".
+// The conditions use the operators:
+//   - "=" or none matches the exact version
+//   - "!=" excludes the exact version
+//   - ">", ">=", "<" and "<=" compare with the version
+//   - "~>" allows only the rightmost segment to increment, e.g. "~> 5.2" means ">= 5.2, < 6.0"
+//     and "~> 5.2.1" means ">= 5.2.1, < 5.3.0"
+type VersionConstraint struct {
+	conditions  []condition
+	constraints version.Constraints
+}
+
+// condition is a condition of a version constraint.
+type condition struct {
+	raw      string
+	op       string
+	version  *version.Version
+	segments int
+}
+
+// ParseVersionConstraint parses the provider version constraint.
+func ParseVersionConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{}
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return nil, fmt.Errorf("%w %q: empty condition", errInvalidVersion, s)
+		}
+		matches := conditionRegexp.FindStringSubmatch(raw)
+		if matches == nil {
+			return nil, fmt.Errorf("%w %q: malformed condition %q", errInvalidVersion, s, raw)
+		}
+		constraints, err := version.NewConstraint(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: malformed condition %q", errInvalidVersion, s, raw)
+		}
+		v, err := version.NewVersion(matches[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: malformed version %q", errInvalidVersion, s, matches[2])
+		}
+		normalized := matches[2]
+		if matches[1] != "" {
+			normalized = matches[1] + " " + normalized
+		}
+		core, _, _ := strings.Cut(strings.SplitN(matches[2], "+", 2)[0], "-")
+		c.conditions = append(c.conditions, condition{
+			raw:      normalized,
+			op:       matches[1],
+			version:  v,
+			segments: strings.Count(core, ".") + 1,
+		})
+		c.constraints = append(c.constraints, constraints...)
+	}
+	return c, nil
+}
+
+// String returns the normalized constraint, e.g. ">= 4.0, < 6.0".
+func (c *VersionConstraint) String() string {
+	conditions := make([]string, len(c.conditions))
+	for i := range c.conditions {
+		conditions[i] = c.conditions[i].raw
+	}
+	return strings.Join(conditions, ", ")
+}
+
+// Check returns whether the version satisfies the constraint. Pre-release versions only satisfy
+// the conditions on the pre-releases of the same version.
+func (c *VersionConstraint) Check(v *version.Version) bool {
+	return c.constraints.Check(v)
+}
+
+// Intersect returns the constraint satisfied by the versions satisfying both constraints, or
+// ErrIncompatibleVersions if no version can satisfy both.
+func (c *VersionConstraint) Intersect(other *VersionConstraint) (*VersionConstraint, error) {
+	merged := &VersionConstraint{}
+	seen := map[string]bool{}
+	for _, constraint := range []*VersionConstraint{c, other} {
+		for i, cond := range constraint.conditions {
+			if seen[cond.raw] {
+				continue
+			}
+			seen[cond.raw] = true
+			merged.conditions = append(merged.conditions, cond)
+			merged.constraints = append(merged.constraints, constraint.constraints[i])
+		}
+	}
+	if !merged.satisfiable() {
+		return nil, fmt.Errorf("%w %q and %q", ErrIncompatibleVersions, c, other)
+	}
+	return merged, nil
+}
+
+// satisfiable returns whether the range of versions allowed by the conditions is not empty.
+func (c *VersionConstraint) satisfiable() bool {
+	var lower, upper *version.Version
+	lowerInclusive, upperInclusive := true, true
+	var excluded []*version.Version
+	raise := func(v *version.Version, inclusive bool) {
+		if lower == nil || v.GreaterThan(lower) || v.Equal(lower) && !inclusive {
+			lower, lowerInclusive = v, inclusive
+		}
+	}
+	reduce := func(v *version.Version, inclusive bool) {
+		if upper == nil || v.LessThan(upper) || v.Equal(upper) && !inclusive {
+			upper, upperInclusive = v, inclusive
+		}
+	}
+	for _, cond := range c.conditions {
+		switch cond.op {
+		case "", "=":
+			raise(cond.version, true)
+			reduce(cond.version, true)
+		case "!=":
+			excluded = append(excluded, cond.version)
+		case ">":
+			raise(cond.version, false)
+		case ">=":
+			raise(cond.version, true)
+		case "<":
+			reduce(cond.version, false)
+		case "<=":
+			reduce(cond.version, true)
+		case "~>":
+			raise(cond.version, true)
+			if cond.segments > 1 {
+				reduce(pessimisticUpper(cond.version, cond.segments), false)
+			}
+		}
+	}
+	if lower == nil || upper == nil {
+		return true
+	}
+	switch lower.Compare(upper) {
+	case 1:
+		return false
+	case 0:
+		if !lowerInclusive || !upperInclusive {
+			return false
+		}
+		for _, v := range excluded {
+			if v.Equal(lower) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pessimisticUpper returns the exclusive upper bound of the "~>" condition on the version with the
+// number of specified segments, e.g. 6.0 of "~> 5.2".
+func pessimisticUpper(v *version.Version, segments int) *version.Version {
+	parts := v.Segments()[:segments-1]
+	parts[len(parts)-1]++
+	strs := make([]string, len(parts), len(parts)+1)
+	for i, part := range parts {
+		strs[i] = fmt.Sprint(part)
+	}
+	return version.Must(version.NewVersion(strings.Join(append(strs, "0"), ".")))
+}
+
+// ValidateProviderVersions checks the version constraints in the provider extensions of the
+// Terraform resources are well-formed, and the resources requiring the same provider can be
+// satisfied by a single version. The problems are returned as *resources.ValidationError joined
+// by errors.Join, the incompatible ones wrap ErrIncompatibleVersions, and the malformed provider
+// extensions are left to resources.ValidateResources.
+func ValidateProviderVersions(rs v1.Resources) error {
+	type requirement struct {
+		ids        []string
+		constraint *VersionConstraint
+	}
+	var errs []error
+	required := map[TFProvider]*requirement{}
+	for i, r := range rs {
+		if r.Type != v1.Terraform {
+			continue
+		}
+		provider, _ := r.Extensions[resources.ExtensionProvider].(string)
+		source, constraint, ok := splitProviderExtension(provider)
+		if !ok {
+			continue
+		}
+		tp, err := parseProviderSourceString(source)
+		if err != nil {
+			continue
+		}
+		c, err := ParseVersionConstraint(constraint)
+		if err != nil {
+			errs = append(errs, &resources.ValidationError{ID: r.ID, Index: i, Message: err.Error()})
+			continue
+		}
+
+		req, ok := required[tp]
+		if !ok {
+			required[tp] = &requirement{ids: []string{r.ID}, constraint: c}
+			continue
+		}
+		// the "!=" conditions make the allowed versions non-convex, so the constraints are
+		// intersected cumulatively instead of pairwise
+		merged, err := req.constraint.Intersect(c)
+		if err != nil {
+			by := "resource " + req.ids[0]
+			if len(req.ids) > 1 {
+				by = "resources " + strings.Join(req.ids, ", ")
+			}
+			errs = append(errs, &resources.ValidationError{ID: r.ID, Index: i, Err: ErrIncompatibleVersions, Message: fmt.Sprintf(
+				"provider %s version %q is incompatible with %q required by %s", source, c, req.constraint, by)})
+			continue
+		}
+		if merged.String() != req.constraint.String() {
+			req.ids = append(req.ids, r.ID)
+			req.constraint = merged
+		}
+	}
+	return errors.Join(errs...)
+}
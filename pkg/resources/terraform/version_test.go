@@ -0,0 +1,129 @@
+package terraform
+
+import (
+	"testing"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/resources"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		normalized string
+		matches    []string
+		mismatches []string
+	}{
+		{constraint: "5.0.1", normalized: "5.0.1", matches: []string{"5.0.1"}, mismatches: []string{"5.0.2"}},
+		{constraint: "= 5.0.1", normalized: "= 5.0.1", matches: []string{"5.0.1"}, mismatches: []string{"5.0.0"}},
+		{constraint: ">=4.0,<6.0", normalized: ">= 4.0, < 6.0", matches: []string{"4.0.0", "5.9.9"}, mismatches: []string{"3.9.0", "6.0.0"}},
+		{constraint: "~> 5.2", normalized: "~> 5.2", matches: []string{"5.2.0", "5.99.1"}, mismatches: []string{"5.1.9", "6.0.0"}},
+		{constraint: "~> 5.2.1", normalized: "~> 5.2.1", matches: []string{"5.2.1", "5.2.9"}, mismatches: []string{"5.2.0", "5.3.0"}},
+		{constraint: ">= 4.0, != 4.5.0", normalized: ">= 4.0, != 4.5.0", matches: []string{"4.4.0"}, mismatches: []string{"4.5.0", "5.0.0-beta1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseVersionConstraint(tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.normalized, c.String())
+			for _, v := range tt.matches {
+				assert.True(t, c.Check(version.Must(version.NewVersion(v))), v)
+			}
+			for _, v := range tt.mismatches {
+				assert.False(t, c.Check(version.Must(version.NewVersion(v))), v)
+			}
+		})
+	}
+
+	for constraint, msg := range map[string]string{
+		"":            `"": empty condition`,
+		">= 4.0,, <5": `">= 4.0,, <5": empty condition`,
+		">= 4.0 < 5":  `malformed condition ">= 4.0 < 5"`,
+		"~>":          `malformed condition "~>"`,
+		"v5.0.1":      `malformed condition "v5.0.1"`,
+		"latest":      `malformed condition "latest"`,
+	} {
+		_, err := ParseVersionConstraint(constraint)
+		assert.ErrorIs(t, err, errInvalidVersion, constraint)
+		assert.ErrorContains(t, err, msg, constraint)
+
+		_, err = NewProvider(nil, "hashicorp/aws", constraint)
+		assert.ErrorIs(t, err, errInvalidVersion, constraint)
+	}
+}
+
+func TestVersionConstraintIntersect(t *testing.T) {
+	tests := []struct {
+		a, b       string
+		compatible bool
+	}{
+		{a: "5.0.1", b: "5.0.1", compatible: true},
+		{a: "5.0.1", b: "4.67.0"},
+		{a: "~> 5.0", b: ">= 5.2", compatible: true},
+		{a: "~> 5.0", b: "4.67.0"},
+		{a: "~> 5.2.1", b: ">= 5.3"},
+		{a: "~> 5", b: "6.1.0", compatible: true},
+		{a: ">= 4.0, < 5.0", b: "<= 4.0", compatible: true},
+		{a: ">= 4.0, < 5.0", b: "< 4.0"},
+		{a: "> 5.0", b: "<= 5.0.0"},
+		{a: "5.0.1", b: "!= 5.0.1"},
+		{a: ">= 5.0, <= 5.1", b: "!= 5.1.0", compatible: true},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersionConstraint(tt.a)
+		require.NoError(t, err)
+		b, err := ParseVersionConstraint(tt.b)
+		require.NoError(t, err)
+
+		merged, err := a.Intersect(b)
+		if !tt.compatible {
+			assert.ErrorIs(t, err, ErrIncompatibleVersions, "%s and %s", tt.a, tt.b)
+			continue
+		}
+		require.NoError(t, err, "%s and %s", tt.a, tt.b)
+		if tt.a == tt.b {
+			assert.Equal(t, tt.a, merged.String())
+		} else {
+			assert.Equal(t, tt.a+", "+tt.b, merged.String())
+		}
+	}
+}
+
+func TestValidateProviderVersions(t *testing.T) {
+	resource := func(id, provider string) v1.Resource {
+		return v1.Resource{ID: id, Type: v1.Terraform, Extensions: map[string]any{resources.ExtensionProvider: provider}}
+	}
+
+	assert.NoError(t, ValidateProviderVersions(v1.Resources{
+		resource("a", "hashicorp/aws/~> 5.0"),
+		resource("b", "registry.terraform.io/hashicorp/aws/5.2.0"),
+		resource("c", "hashicorp/random/3.6.0"),
+		{ID: "d", Type: v1.Kubernetes},
+	}))
+
+	err := ValidateProviderVersions(v1.Resources{
+		resource("a", "hashicorp/aws/~> 5.0"),
+		resource("b", "hashicorp/aws/~> 5.0"),
+		resource("c", "registry.terraform.io/hashicorp/aws/4.67.0"),
+		resource("d", "hashicorp/random/>= 3.0,, < 4"),
+	})
+	assert.EqualError(t, err, `resource c: provider registry.terraform.io/hashicorp/aws version "4.67.0" is incompatible with "~> 5.0" required by resource a
+resource d: invalid provider version constraint ">= 3.0,, < 4": empty condition`)
+	var validationErr *resources.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 2, validationErr.Index)
+	assert.ErrorIs(t, err, ErrIncompatibleVersions)
+
+	// the constraints intersect pairwise, but no version satisfies all of them
+	err = ValidateProviderVersions(v1.Resources{
+		resource("a", "hashicorp/aws/>= 1, <= 2"),
+		resource("b", "hashicorp/aws/>= 2, <= 3"),
+		resource("c", "hashicorp/aws/!= 2"),
+	})
+	assert.EqualError(t, err, `resource c: provider hashicorp/aws version "!= 2" is incompatible with ">= 1, <= 2, >= 2, <= 3" required by resources a, b`)
+	assert.ErrorIs(t, err, ErrIncompatibleVersions)
+}
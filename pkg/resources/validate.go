@@ -0,0 +1,158 @@
+// Copyright 2024 KusionStack Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// ValidationMode decides how the problems of the generated resources are handled.
+type ValidationMode string
+
+const (
+	// ValidationStrict fails the generation if any resource is invalid.
+	ValidationStrict ValidationMode = "strict"
+	// ValidationLenient reports the problems of the resources as warnings.
+	ValidationLenient ValidationMode = "lenient"
+)
+
+const (
+	// ExtensionProvider is the Terraform resource extension of the provider address, in the format
+	// "[hostname/]namespace/name/version".
+	ExtensionProvider = "provider"
+	// ExtensionProviderMeta is the Terraform resource extension of the provider configuration
+	// arguments.
+	ExtensionProviderMeta = "providerMeta"
+	// ExtensionResourceType is the Terraform resource extension of the resource type.
+	ExtensionResourceType = "resourceType"
+	// ExtensionImportID is the Terraform resource extension of the ID of the existing resource to
+	// import.
+	ExtensionImportID = "kusionstack.io/import-id"
+)
+
+// ValidationError is a problem of a resource.
+type ValidationError struct {
+	// ID is the ID of the invalid resource.
+	ID string
+	// Index is the index of the invalid resource.
+	Index int
+	// Message describes the problem.
+	Message string
+	// Err is the cause of the problem, if any.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("resource at index %d: %s", e.Index, e.Message)
+	}
+	return fmt.Sprintf("resource %s: %s", e.ID, e.Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateResources checks the resources have unique IDs, their dependsOn point to either the
+// resources or the externalIDs, and the extensions required by their types are well-formed. All
+// problems are returned as *ValidationError joined by errors.Join.
+func ValidateResources(rs v1.Resources, externalIDs ...string) error {
+	known := make(map[string]bool, len(rs)+len(externalIDs))
+	for _, id := range externalIDs {
+		known[id] = true
+	}
+	for _, r := range rs {
+		known[r.ID] = true
+	}
+
+	var errs []error
+	report := func(i int, format string, args ...any) {
+		errs = append(errs, &ValidationError{ID: rs[i].ID, Index: i, Message: fmt.Sprintf(format, args...)})
+	}
+	seen := make(map[string]bool, len(rs))
+	for i, r := range rs {
+		if r.ID == "" {
+			report(i, "empty resource id")
+		} else if seen[r.ID] {
+			report(i, "duplicate resource id")
+		}
+		seen[r.ID] = true
+
+		for _, dep := range r.DependsOn {
+			if !known[dep] {
+				report(i, "dependsOn %s doesn't exist", dep)
+			}
+		}
+
+		switch r.Type {
+		case v1.Kubernetes:
+			if err := validateGVKExtension(r.Extensions); err != nil {
+				report(i, "%v", err)
+			}
+		case v1.Terraform:
+			for _, err := range validateTerraformExtensions(r.Extensions) {
+				report(i, "%v", err)
+			}
+		default:
+			report(i, "unknown resource type %q", r.Type)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateGVKExtension checks the GVK extension is in the format of schema.GroupVersionKind.String(),
+// e.g. "apps/v1, Kind=Deployment" and "/v1, Kind=Service".
+func validateGVKExtension(extensions map[string]any) error {
+	value, ok := extensions[v1.ResourceExtensionGVK]
+	if !ok {
+		return fmt.Errorf("missing %s extension", v1.ResourceExtensionGVK)
+	}
+	gvk, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s extension must be a string, got %T", v1.ResourceExtensionGVK, value)
+	}
+	groupVersion, kind, found := strings.Cut(gvk, ", Kind=")
+	if !found || kind == "" || strings.HasSuffix(groupVersion, "/") || groupVersion == "" {
+		return fmt.Errorf("malformed %s extension %q", v1.ResourceExtensionGVK, gvk)
+	}
+	return nil
+}
+
+// validateTerraformExtensions checks the provider and resourceType extensions.
+func validateTerraformExtensions(extensions map[string]any) []error {
+	var errs []error
+	provider, ok := extensions[ExtensionProvider].(string)
+	if !ok || provider == "" {
+		errs = append(errs, fmt.Errorf("missing %s extension", ExtensionProvider))
+	} else {
+		parts := strings.Split(provider, "/")
+		valid := len(parts) >= 2 && len(parts) <= 4
+		for _, part := range parts {
+			valid = valid && part != ""
+		}
+		if !valid {
+			errs = append(errs, fmt.Errorf("malformed %s extension %q, must be in the format \"[hostname/]namespace/name/version\"",
+				ExtensionProvider, provider))
+		}
+	}
+	if resourceType, ok := extensions[ExtensionResourceType].(string); !ok || resourceType == "" {
+		errs = append(errs, fmt.Errorf("missing %s extension", ExtensionResourceType))
+	}
+	return errs
+}
@@ -0,0 +1,88 @@
+package resources
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestValidateResources(t *testing.T) {
+	deployment := v1.Resource{
+		ID:         "apps/v1:Deployment:foo:bar",
+		Type:       v1.Kubernetes,
+		Extensions: map[string]any{v1.ResourceExtensionGVK: "apps/v1, Kind=Deployment"},
+		DependsOn:  []string{"v1:Service:foo:bar", "v1:Namespace:foo"},
+	}
+	service := v1.Resource{
+		ID:         "v1:Service:foo:bar",
+		Type:       v1.Kubernetes,
+		Extensions: map[string]any{v1.ResourceExtensionGVK: "/v1, Kind=Service"},
+	}
+	db := v1.Resource{
+		ID:   "hashicorp:aws:aws_db_instance:db",
+		Type: v1.Terraform,
+		Extensions: map[string]any{
+			ExtensionProvider:     "registry.terraform.io/hashicorp/aws/5.0.1",
+			ExtensionResourceType: "aws_db_instance",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		resources v1.Resources
+		external  []string
+		expected  []string
+	}{
+		{
+			name:      "valid resources",
+			resources: v1.Resources{deployment, service, db},
+			external:  []string{"v1:Namespace:foo"},
+		},
+		{
+			name:      "duplicate id and dangling dependsOn",
+			resources: v1.Resources{deployment, service, service},
+			expected: []string{
+				"resource apps/v1:Deployment:foo:bar: dependsOn v1:Namespace:foo doesn't exist",
+				"resource v1:Service:foo:bar: duplicate resource id",
+			},
+		},
+		{
+			name: "malformed extensions",
+			resources: v1.Resources{
+				{ID: "a", Type: v1.Kubernetes},
+				{ID: "b", Type: v1.Kubernetes, Extensions: map[string]any{v1.ResourceExtensionGVK: "Deployment"}},
+				{ID: "c", Type: v1.Terraform, Extensions: map[string]any{ExtensionProvider: "aws"}},
+				{Type: "Unknown"},
+			},
+			expected: []string{
+				"resource a: missing GVK extension",
+				`resource b: malformed GVK extension "Deployment"`,
+				`resource c: malformed provider extension "aws", must be in the format "[hostname/]namespace/name/version"`,
+				"resource c: missing resourceType extension",
+				"resource at index 3: empty resource id",
+				`resource at index 3: unknown resource type "Unknown"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResources(tt.resources, tt.external...)
+			if len(tt.expected) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			require.True(t, ok)
+			var msgs []string
+			for _, e := range joined.Unwrap() {
+				var ve *ValidationError
+				assert.True(t, errors.As(e, &ve))
+				msgs = append(msgs, e.Error())
+			}
+			assert.Equal(t, tt.expected, msgs)
+		})
+	}
+}
@@ -7,18 +7,13 @@ import (
 )
 
 // HandshakeConfig is a common handshake that is shared by plugin and host.
-var HandshakeConfig = plugin.HandshakeConfig{
-	ProtocolVersion:  1,
-	MagicCookieKey:   "MODULE_PLUGIN",
-	MagicCookieValue: "ON",
-}
+var HandshakeConfig = module.HandshakeConfig
 
 func Start(m module.FrameworkModule) {
 	plugin.Serve(&plugin.ServeConfig{
 		HandshakeConfig: HandshakeConfig,
-		Plugins: map[string]plugin.Plugin{
-			module.PluginKey: &module.GRPCPlugin{Impl: &module.FrameworkModuleWrapper{Module: m}},
-		},
+		// serve all protocol versions, the newest one supported by the host is negotiated
+		VersionedPlugins: module.VersionedPluginSets(&module.FrameworkModuleWrapper{Module: m}),
 
 		// A non-nil value here enables gRPC serving for this plugin...
 		GRPCServer: plugin.DefaultGRPCServer,
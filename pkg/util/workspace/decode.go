@@ -0,0 +1,390 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/schema"
+)
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonUnmarshalType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// FieldError is an error of decoding a config field.
+type FieldError struct {
+	// Path is the path of the field, e.g. "database.ports[0]".
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Decode decodes the config into a value of type T, which is usually a struct. The config keys of the
+// struct fields are taken from the yaml tag, then the json tag, then the lowercased field name, the
+// same as the JSON schemas published by modules. Decode supports:
+//   - nested structs, pointers, slices and maps
+//   - time.Duration from strings like "1m30s"
+//   - types implementing json.Unmarshaler, e.g. resource.Quantity and intstr.IntOrString
+//   - default values of missing fields from the `default:"..."` tag
+//   - required fields tagged with `kusion:"required"`
+//
+// A nil config is decoded as an empty config if T is a struct, and so is a missing nested struct, so
+// their default values and required fields apply. A missing pointer to a struct is left nil. Keys
+// without a matching field are errors. All errors are returned as *FieldError joined by errors.Join.
+func Decode[T any](cfg v1.GenericConfig) (T, error) {
+	var out T
+	d := &decoder{}
+	var in any
+	if cfg != nil {
+		in = map[string]any(cfg)
+	} else if t := reflect.TypeOf(&out).Elem(); t.Kind() == reflect.Struct ||
+		t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		// a nil config of a struct still gets the default values and the required checks
+		in = map[string]any{}
+	}
+	d.decode("", in, reflect.ValueOf(&out).Elem())
+	return out, errors.Join(d.errs...)
+}
+
+type decoder struct {
+	errs []error
+}
+
+func (d *decoder) fail(path string, format string, args ...any) {
+	d.errs = append(d.errs, &FieldError{Path: path, Err: fmt.Errorf(format, args...)})
+}
+
+func (d *decoder) decode(path string, in any, out reflect.Value) {
+	if in == nil {
+		return
+	}
+	t := out.Type()
+
+	if t.Kind() == reflect.Pointer {
+		if out.IsNil() {
+			out.Set(reflect.New(t.Elem()))
+		}
+		d.decode(path, in, out.Elem())
+		return
+	}
+	if t == durationType {
+		s, ok := in.(string)
+		if !ok {
+			d.fail(path, "expected a duration string, got %T", in)
+			return
+		}
+		duration, err := time.ParseDuration(s)
+		if err != nil {
+			d.fail(path, "invalid duration %q", s)
+			return
+		}
+		out.SetInt(int64(duration))
+		return
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalType) {
+		data, err := json.Marshal(toJSONValue(in))
+		if err == nil {
+			err = out.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		}
+		if err != nil {
+			d.fail(path, "%v", err)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if !reflect.TypeOf(in).AssignableTo(t) {
+			d.fail(path, "%T doesn't implement %s", in, t)
+			return
+		}
+		out.Set(reflect.ValueOf(in))
+	case reflect.Bool:
+		b, ok := in.(bool)
+		if !ok {
+			d.fail(path, "expected a boolean, got %T", in)
+			return
+		}
+		out.SetBool(b)
+	case reflect.String:
+		s, ok := in.(string)
+		if !ok {
+			d.fail(path, "expected a string, got %T", in)
+			return
+		}
+		out.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt64(in)
+		if !ok {
+			d.fail(path, "expected an integer, got %v", in)
+			return
+		}
+		if out.OverflowInt(i) {
+			d.fail(path, "%d overflows %s", i, t)
+			return
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := toInt64(in)
+		if !ok || i < 0 {
+			d.fail(path, "expected a non-negative integer, got %v", in)
+			return
+		}
+		if out.OverflowUint(uint64(i)) {
+			d.fail(path, "%d overflows %s", i, t)
+			return
+		}
+		out.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat64(in)
+		if !ok {
+			d.fail(path, "expected a number, got %T", in)
+			return
+		}
+		out.SetFloat(f)
+	case reflect.Slice:
+		items, ok := in.([]any)
+		if !ok {
+			d.fail(path, "expected a list, got %T", in)
+			return
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			d.decode(fmt.Sprintf("%s[%d]", path, i), item, slice.Index(i))
+		}
+		out.Set(slice)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			d.fail(path, "unsupported map key type %s", t.Key())
+			return
+		}
+		entries, ok := toStringMap(in)
+		if !ok {
+			d.fail(path, "expected a map, got %T", in)
+			return
+		}
+		m := reflect.MakeMapWithSize(t, len(entries))
+		for _, key := range sortedMapKeys(entries) {
+			value := reflect.New(t.Elem()).Elem()
+			d.decode(joinPath(path, key), entries[key], value)
+			m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), value)
+		}
+		out.Set(m)
+	case reflect.Struct:
+		entries, ok := toStringMap(in)
+		if !ok {
+			d.fail(path, "expected a map, got %T", in)
+			return
+		}
+		used := map[string]bool{}
+		d.decodeStruct(path, entries, out, used)
+		for _, key := range sortedMapKeys(entries) {
+			if !used[key] {
+				d.fail(joinPath(path, key), "unknown field")
+			}
+		}
+	default:
+		d.fail(path, "unsupported type %s", t)
+	}
+}
+
+// decodeStruct decodes the entries into the fields of the struct, the inlined fields are flattened.
+func (d *decoder) decodeStruct(path string, entries map[string]any, out reflect.Value, used map[string]bool) {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, inline, skip := schema.FieldName(f)
+		if skip {
+			continue
+		}
+		field := out.Field(i)
+		if inline {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if f.Type.Kind() == reflect.Pointer {
+					if !f.IsExported() {
+						continue
+					}
+					if field.IsNil() {
+						field.Set(reflect.New(ft))
+					}
+					field = field.Elem()
+				}
+				d.decodeStruct(path, entries, field, used)
+				continue
+			}
+			if name == "" {
+				continue
+			}
+		}
+
+		fieldPath := joinPath(path, name)
+		value, ok := entries[name]
+		if !ok {
+			if def, found := f.Tag.Lookup(schema.TagDefault); found {
+				d.decode(fieldPath, defaultValue(def, f.Type), field)
+			} else if schema.HasOption(f, schema.OptionRequired) {
+				d.fail(fieldPath, "required field is missing")
+			} else if isNestedStruct(f.Type) {
+				// the defaults and the required fields of a missing nested struct still apply
+				d.decode(fieldPath, map[string]any{}, field)
+			}
+			continue
+		}
+		used[name] = true
+		if value == nil && schema.HasOption(f, schema.OptionRequired) {
+			d.fail(fieldPath, "required field is null")
+			continue
+		}
+		d.decode(fieldPath, value, field)
+	}
+}
+
+// isNestedStruct returns whether the type is a struct decoded field by field. The pointers to structs
+// are optional blocks, which are left nil if missing.
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(jsonUnmarshalType)
+}
+
+// defaultValue parses the default tag of a field. Strings are used as they are, other values are
+// parsed as YAML, e.g. `default:"3"` and `default:"[a, b]"`.
+func defaultValue(def string, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String || t == durationType {
+		return def
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(def), &v); err != nil {
+		return def
+	}
+	return v
+}
+
+func toInt64(in any) (int64, bool) {
+	switch v := in.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float32:
+		return toInt64(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func toFloat64(in any) (float64, bool) {
+	switch v := in.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		if i, ok := toInt64(in); ok {
+			return float64(i), true
+		}
+		return 0, false
+	}
+}
+
+// toStringMap converts the maps decoded by yaml.v3 and yaml.v2 into map[string]any.
+func toStringMap(in any) (map[string]any, bool) {
+	switch v := in.(type) {
+	case map[string]any:
+		return v, true
+	case v1.GenericConfig:
+		return v, true
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[fmt.Sprint(key)] = value
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// toJSONValue converts the maps with interface keys decoded by yaml.v2 to be marshaled as JSON.
+func toJSONValue(in any) any {
+	switch v := in.(type) {
+	case map[any]any:
+		out, _ := toStringMap(v)
+		return toJSONValue(out)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = toJSONValue(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = toJSONValue(value)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package workspace
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+type port struct {
+	Name string `yaml:"name" kusion:"required"`
+	Port int32  `yaml:"port" default:"80"`
+}
+
+type common struct {
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type databaseConfig struct {
+	common       `yaml:",inline"`
+	InstanceType string            `yaml:"instanceType" kusion:"required"`
+	Size         resource.Quantity `yaml:"size" default:"10Gi"`
+	Timeout      time.Duration     `yaml:"timeout" default:"30s"`
+	Replicas     *int              `yaml:"replicas"`
+	Ratio        float64           `yaml:"ratio"`
+	Ports        []port            `yaml:"ports"`
+	Extra        any               `yaml:"extra"`
+}
+
+func TestDecode(t *testing.T) {
+	cfg := v1.GenericConfig{
+		"instanceType": "db.t3.micro",
+		"size":         "20Gi",
+		"replicas":     3,
+		"ratio":        1,
+		"labels":       map[string]any{"app": "db"},
+		"ports": []any{
+			map[string]any{"name": "mysql", "port": 3306},
+			map[any]any{"name": "admin"},
+		},
+		"extra": []any{"a"},
+	}
+
+	got, err := Decode[databaseConfig](cfg)
+	require.NoError(t, err)
+	replicas := 3
+	assert.Equal(t, databaseConfig{
+		common:       common{Labels: map[string]string{"app": "db"}},
+		InstanceType: "db.t3.micro",
+		Size:         resource.MustParse("20Gi"),
+		Timeout:      30 * time.Second,
+		Replicas:     &replicas,
+		Ratio:        1,
+		Ports:        []port{{Name: "mysql", Port: 3306}, {Name: "admin", Port: 80}},
+		Extra:        []any{"a"},
+	}, got)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cfg := v1.GenericConfig{
+		"size":    "20Gx",
+		"timeout": "soon",
+		"ratio":   "high",
+		"ports": []any{
+			map[string]any{"port": 3000000000},
+		},
+		"unknown": true,
+	}
+
+	_, err := Decode[databaseConfig](cfg)
+	require.Error(t, err)
+
+	var msgs []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *FieldError
+		require.True(t, errors.As(e, &fe))
+		msgs = append(msgs, fe.Path)
+	}
+	assert.Equal(t, []string{
+		"instanceType",
+		"size",
+		"timeout",
+		"ratio",
+		"ports[0].name",
+		"ports[0].port",
+		"unknown",
+	}, msgs)
+	assert.ErrorContains(t, err, "ports[0].port: 3000000000 overflows int32")
+	assert.ErrorContains(t, err, "unknown: unknown field")
+}
+
+func TestDecodeNilConfig(t *testing.T) {
+	_, err := Decode[databaseConfig](nil)
+	assert.EqualError(t, err, "instanceType: required field is missing")
+	_, err = Decode[*databaseConfig](nil)
+	assert.EqualError(t, err, "instanceType: required field is missing")
+
+	got, err := Decode[struct {
+		Port int32 `yaml:"port" default:"80"`
+	}](nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(80), got.Port)
+
+	type backup struct {
+		Schedule string `yaml:"schedule" kusion:"required"`
+		Retain   int    `yaml:"retain" default:"7"`
+	}
+	type withBackup struct {
+		Backup   backup  `yaml:"backup"`
+		Optional *backup `yaml:"optional"`
+	}
+	// the missing nested struct gets its defaults and required checks, but not the pointer
+	nested, err := Decode[withBackup](v1.GenericConfig{})
+	assert.EqualError(t, err, "backup.schedule: required field is missing")
+	assert.Equal(t, 7, nested.Backup.Retain)
+	assert.Nil(t, nested.Optional)
+	nested, err = Decode[withBackup](v1.GenericConfig{"backup": map[string]any{"schedule": "@daily"}})
+	require.NoError(t, err)
+	assert.Equal(t, backup{Schedule: "@daily", Retain: 7}, nested.Backup)
+
+	m, err := Decode[map[string]int](v1.GenericConfig{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, m)
+}
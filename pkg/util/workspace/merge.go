@@ -0,0 +1,145 @@
+package workspace
+
+import (
+	"fmt"
+
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+// ListStrategy is how a list in the patch is merged with the list in the base config.
+type ListStrategy string
+
+const (
+	// ListReplace replaces the base list with the patch list.
+	ListReplace ListStrategy = "replace"
+	// ListAppend appends the items of the patch list to the base list.
+	ListAppend ListStrategy = "append"
+	// ListMergeByKey deep merges the map items with the same merge key, and appends the others.
+	ListMergeByKey ListStrategy = "mergeByKey"
+)
+
+// DefaultMergeKey is the default key identifying the map items of the lists merged by key.
+const DefaultMergeKey = "name"
+
+// MergeOptions are the options to merge configs.
+type MergeOptions struct {
+	// ListStrategy is the strategy of merging lists, ListReplace is used if it is empty.
+	ListStrategy ListStrategy
+	// ListStrategies overrides the strategy of the lists at the dotted paths, e.g. "env" and
+	// "containers.ports", the indexes of lists are not part of the paths.
+	ListStrategies map[string]ListStrategy
+	// MergeKey is the key of the lists merged by key, DefaultMergeKey is used if it is empty.
+	MergeKey string
+}
+
+// MergeGenericConfigs deep merges the patch into the base config and returns the merged config:
+//   - maps are merged recursively
+//   - a null value in the patch deletes the key
+//   - lists are merged with the list strategies
+//   - other values in the patch replace the values in the base
+//
+// The base and the patch are not modified. The merge is copy-on-write, nested values not changed
+// by the patch are shared with the base.
+func MergeGenericConfigs(base, patch v1.GenericConfig, opts *MergeOptions) v1.GenericConfig {
+	if opts == nil {
+		opts = &MergeOptions{}
+	}
+	if base == nil && patch == nil {
+		return nil
+	}
+	return mergeMaps("", base, patch, opts)
+}
+
+func mergeMaps(path string, base, patch map[string]any, opts *MergeOptions) map[string]any {
+	merged := make(map[string]any, len(base)+len(patch))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if old, ok := merged[k]; ok {
+			merged[k] = mergeValues(joinPath(path, k), old, v, opts)
+		} else if m, ok := toStringMap(v); ok {
+			// drop the null values in the new map
+			merged[k] = mergeMaps(joinPath(path, k), nil, m, opts)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func mergeValues(path string, base, patch any, opts *MergeOptions) any {
+	if baseMap, ok := toStringMap(base); ok {
+		if patchMap, ok := toStringMap(patch); ok {
+			return mergeMaps(path, baseMap, patchMap, opts)
+		}
+	}
+	baseList, ok := base.([]any)
+	if !ok {
+		return patch
+	}
+	patchList, ok := patch.([]any)
+	if !ok {
+		return patch
+	}
+
+	strategy := opts.ListStrategy
+	if s, ok := opts.ListStrategies[path]; ok {
+		strategy = s
+	}
+	switch strategy {
+	case ListAppend:
+		merged := make([]any, 0, len(baseList)+len(patchList))
+		return append(append(merged, baseList...), patchList...)
+	case ListMergeByKey:
+		return mergeListByKey(path, baseList, patchList, opts)
+	default:
+		return patch
+	}
+}
+
+// mergeListByKey deep merges the map items of the lists with the same value of the merge key, the
+// other items of the patch are appended.
+func mergeListByKey(path string, base, patch []any, opts *MergeOptions) []any {
+	key := opts.MergeKey
+	if key == "" {
+		key = DefaultMergeKey
+	}
+	merged := append(make([]any, 0, len(base)+len(patch)), base...)
+	index := map[string]int{}
+	for i, item := range base {
+		if id, ok := mergeKeyOf(item, key); ok {
+			index[id] = i
+		}
+	}
+	for _, item := range patch {
+		id, ok := mergeKeyOf(item, key)
+		if !ok {
+			merged = append(merged, item)
+			continue
+		}
+		if i, found := index[id]; found {
+			merged[i] = mergeValues(path, merged[i], item, opts)
+			continue
+		}
+		index[id] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func mergeKeyOf(item any, key string) (string, bool) {
+	m, ok := toStringMap(item)
+	if !ok {
+		return "", false
+	}
+	v, ok := m[key]
+	if !ok || v == nil {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
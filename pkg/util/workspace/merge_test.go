@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestMergeGenericConfigs(t *testing.T) {
+	base := v1.GenericConfig{
+		"instanceType": "db.t3.micro",
+		"labels":       map[string]any{"app": "db", "team": "infra"},
+		"backup":       map[string]any{"enabled": true, "retention": 7},
+		"ports": []any{
+			map[string]any{"name": "mysql", "port": 3306},
+		},
+		"tags": []any{"a"},
+	}
+	patch := v1.GenericConfig{
+		"labels": map[string]any{"team": "payments", "app": nil},
+		"backup": nil,
+		"ports": []any{
+			map[string]any{"name": "mysql", "protocol": "TCP"},
+			map[string]any{"name": "admin", "port": 33062},
+		},
+		"tags":  []any{"b"},
+		"extra": map[string]any{"a": 1, "b": nil},
+	}
+
+	tests := []struct {
+		name     string
+		opts     *MergeOptions
+		expected v1.GenericConfig
+	}{
+		{
+			name: "replace lists",
+			expected: v1.GenericConfig{
+				"instanceType": "db.t3.micro",
+				"labels":       map[string]any{"team": "payments"},
+				"ports": []any{
+					map[string]any{"name": "mysql", "protocol": "TCP"},
+					map[string]any{"name": "admin", "port": 33062},
+				},
+				"tags":  []any{"b"},
+				"extra": map[string]any{"a": 1},
+			},
+		},
+		{
+			name: "append and merge lists by key",
+			opts: &MergeOptions{
+				ListStrategy:   ListAppend,
+				ListStrategies: map[string]ListStrategy{"ports": ListMergeByKey},
+			},
+			expected: v1.GenericConfig{
+				"instanceType": "db.t3.micro",
+				"labels":       map[string]any{"team": "payments"},
+				"ports": []any{
+					map[string]any{"name": "mysql", "port": 3306, "protocol": "TCP"},
+					map[string]any{"name": "admin", "port": 33062},
+				},
+				"tags":  []any{"a", "b"},
+				"extra": map[string]any{"a": 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MergeGenericConfigs(base, patch, tt.opts))
+		})
+	}
+
+	// the inputs are not modified
+	assert.Equal(t, map[string]any{"app": "db", "team": "infra"}, base["labels"])
+	assert.Equal(t, map[string]any{"name": "mysql", "port": 3306}, base["ports"].([]any)[0])
+	assert.Nil(t, MergeGenericConfigs(nil, nil, nil))
+}
+
+func TestGetProjectModuleConfigDeepMerge(t *testing.T) {
+	config := &v1.ModuleConfig{
+		Configs: v1.Configs{
+			Default: v1.GenericConfig{"labels": map[string]any{"app": "db", "team": "infra"}},
+			ModulePatcherConfigs: v1.ModulePatcherConfigs{
+				"payments": {
+					GenericConfig:   v1.GenericConfig{"labels": map[string]any{"team": "payments"}},
+					ProjectSelector: []string{"foo"},
+				},
+			},
+		},
+	}
+
+	cfg, err := GetProjectModuleConfig(config, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"labels": map[string]any{"app": "db", "team": "payments"}}, cfg)
+	assert.Equal(t, map[string]any{"app": "db", "team": "infra"}, config.Configs.Default["labels"])
+}
@@ -0,0 +1,221 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+const (
+	// LabelSelectorPrefix is the prefix of the project selectors matching the project labels, e.g.
+	// "labels:team=payments,env in (prod,staging)".
+	LabelSelectorPrefix = "labels:"
+	// StackSeparator separates the project and stack patterns of a project selector, e.g. "foo/prod".
+	StackSeparator = "/"
+)
+
+// Project is the metadata of a project matched by the project selectors.
+type Project struct {
+	Name   string
+	Stack  string
+	Labels map[string]string
+}
+
+// Selector is a parsed project selector, which is one of:
+//   - a project name or glob pattern, e.g. "foo" and "foo-*"
+//   - a project and a stack name or glob pattern, e.g. "foo/prod" and "*/prod"
+//   - a label selector over the project labels prefixed with "labels:", e.g. "labels:team=payments"
+type Selector struct {
+	raw      string
+	project  string
+	stack    string
+	hasStack bool
+	labels   labels.Selector
+}
+
+// ParseSelector parses the project selector.
+func ParseSelector(s string) (*Selector, error) {
+	sel := &Selector{raw: s}
+	if strings.HasPrefix(s, LabelSelectorPrefix) {
+		ls, err := labels.Parse(strings.TrimPrefix(s, LabelSelectorPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", s, err)
+		}
+		if ls.Empty() {
+			return nil, fmt.Errorf("invalid label selector %q: empty selector", s)
+		}
+		sel.labels = ls
+		return sel, nil
+	}
+
+	sel.project, sel.stack, sel.hasStack = strings.Cut(s, StackSeparator)
+	patterns := []string{sel.project}
+	if sel.hasStack {
+		patterns = append(patterns, sel.stack)
+	}
+	for _, pattern := range patterns {
+		if pattern == "" || strings.Contains(pattern, StackSeparator) {
+			return nil, fmt.Errorf("invalid project selector %q", s)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid project selector %q: %w", s, err)
+		}
+	}
+	return sel, nil
+}
+
+// String returns the raw selector.
+func (s *Selector) String() string {
+	return s.raw
+}
+
+// Matches returns whether the selector matches the project.
+func (s *Selector) Matches(p Project) bool {
+	if s.labels != nil {
+		return s.labels.Matches(labels.Set(p.Labels))
+	}
+	if ok, _ := path.Match(s.project, p.Name); !ok {
+		return false
+	}
+	if !s.hasStack {
+		return true
+	}
+	ok, _ := path.Match(s.stack, p.Stack)
+	return ok
+}
+
+// Specificity returns how specific the selector is, a block selected by a more specific selector
+// takes precedence. An exact project name is more specific than a glob pattern or a label selector,
+// and a stack makes a selector more specific, an exact stack more than a glob pattern.
+func (s *Selector) Specificity() int {
+	if s.labels != nil {
+		return 3
+	}
+	score := 3 * patternSpecificity(s.project)
+	if s.hasStack {
+		score += patternSpecificity(s.stack)
+	}
+	return score
+}
+
+func patternSpecificity(pattern string) int {
+	if strings.ContainsAny(pattern, `*?[\`) {
+		return 1
+	}
+	return 2
+}
+
+// ConfigSource is where a key of the resolved module config comes from.
+type ConfigSource struct {
+	// Block is the name of the patcher block, or v1.DefaultBlock for the default config.
+	Block string `json:"block" yaml:"block"`
+	// Selector is the project selector matching the project, empty for the default config.
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
+}
+
+// Explanation explains how the module config of a project is resolved.
+type Explanation struct {
+	// Config is the resolved module config.
+	Config v1.GenericConfig `json:"config" yaml:"config"`
+	// Blocks are the matched patcher blocks in the order they are applied, the latter ones
+	// take precedence.
+	Blocks []ConfigSource `json:"blocks,omitempty" yaml:"blocks,omitempty"`
+	// Sources maps each top-level key of the config to the last block setting it.
+	Sources map[string]ConfigSource `json:"sources" yaml:"sources"`
+}
+
+// ResolveProjectModuleConfig returns the module config of the project. The default config is deep
+// merged with all blocks matching the project by MergeGenericConfigs, from the least specific block
+// to the most specific one, and blocks with the same specificity are applied in the reverse order of
+// their names, so the first name wins. Unlike GetProjectModuleConfig, which only applies the most
+// specific block, all matched blocks are layered. The config of the module is not modified, and nil
+// is returned if the resolved config is empty.
+func ResolveProjectModuleConfig(config *v1.ModuleConfig, project Project, opts *MergeOptions) (v1.GenericConfig, error) {
+	e, err := ExplainProjectModuleConfig(config, project, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Config) == 0 {
+		return nil, nil
+	}
+	return e.Config, nil
+}
+
+// ExplainProjectModuleConfig resolves the module config of the project like ResolveProjectModuleConfig,
+// and reports the patcher block supplying each key.
+func ExplainProjectModuleConfig(config *v1.ModuleConfig, project Project, opts *MergeOptions) (*Explanation, error) {
+	if config == nil {
+		return nil, errors.New("empty module config")
+	}
+	e := &Explanation{Config: MergeGenericConfigs(config.Configs.Default, nil, opts), Sources: map[string]ConfigSource{}}
+	if e.Config == nil {
+		e.Config = v1.GenericConfig{}
+	}
+	for k := range e.Config {
+		e.Sources[k] = ConfigSource{Block: v1.DefaultBlock}
+	}
+
+	type match struct {
+		name        string
+		selector    string
+		specificity int
+		block       *v1.ModulePatcherConfig
+	}
+	var matches []match
+	for name, cfg := range config.Configs.ModulePatcherConfigs {
+		if name == v1.DefaultBlock || cfg == nil {
+			continue
+		}
+		m := match{name: name, specificity: -1, block: cfg}
+		for _, raw := range cfg.ProjectSelector {
+			sel, err := ParseSelector(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w, patcher block: %s", err, name)
+			}
+			if sel.Matches(project) && sel.Specificity() > m.specificity {
+				m.selector = raw
+				m.specificity = sel.Specificity()
+			}
+		}
+		if m.specificity >= 0 {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].specificity != matches[j].specificity {
+			return matches[i].specificity < matches[j].specificity
+		}
+		return matches[i].name > matches[j].name
+	})
+
+	for _, m := range matches {
+		source := ConfigSource{Block: m.name, Selector: m.selector}
+		e.Blocks = append(e.Blocks, source)
+		patch := patcherConfig(m.block)
+		for k, v := range patch {
+			if v == nil {
+				delete(e.Sources, k)
+			} else {
+				e.Sources[k] = source
+			}
+		}
+		e.Config = MergeGenericConfigs(e.Config, patch, opts)
+	}
+	return e, nil
+}
+
+// patcherConfig returns the config of the patcher block without the project selector.
+func patcherConfig(block *v1.ModulePatcherConfig) v1.GenericConfig {
+	patch := make(v1.GenericConfig, len(block.GenericConfig))
+	for k, v := range block.GenericConfig {
+		if k != v1.ProjectSelectorField {
+			patch[k] = v
+		}
+	}
+	return patch
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "kusionstack.io/kusion-api-go/api.kusion.io/v1"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	project := Project{Name: "payment-api", Stack: "prod", Labels: map[string]string{"team": "payments"}}
+	tests := []struct {
+		selector    string
+		matches     bool
+		specificity int
+	}{
+		{selector: "payment-api", matches: true, specificity: 6},
+		{selector: "payment-*", matches: true, specificity: 3},
+		{selector: "payment-api/prod", matches: true, specificity: 8},
+		{selector: "payment-api/dev", matches: false, specificity: 8},
+		{selector: "*/prod", matches: true, specificity: 5},
+		{selector: "payment-api/*", matches: true, specificity: 7},
+		{selector: "labels:team=payments", matches: true, specificity: 3},
+		{selector: "labels:team in (infra)", matches: false, specificity: 3},
+		{selector: "order-api", matches: false, specificity: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := ParseSelector(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, sel.Matches(project))
+			assert.Equal(t, tt.specificity, sel.Specificity())
+		})
+	}
+
+	for _, invalid := range []string{"", "foo/", "/prod", "a/b/c", "foo[", "labels:", "labels:a=b=c"} {
+		_, err := ParseSelector(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestExplainProjectModuleConfig(t *testing.T) {
+	config := &v1.ModuleConfig{
+		Configs: v1.Configs{
+			Default: v1.GenericConfig{"instanceType": "db.t3.micro", "size": 10, "region": "us-east-1"},
+			ModulePatcherConfigs: v1.ModulePatcherConfigs{
+				"payments": {
+					GenericConfig:   v1.GenericConfig{"instanceType": "db.t3.small", "size": 20},
+					ProjectSelector: []string{"labels:team=payments"},
+				},
+				"prod": {
+					GenericConfig:   v1.GenericConfig{"instanceType": "db.t3.large"},
+					ProjectSelector: []string{"*/prod"},
+				},
+				"payment-a": {
+					GenericConfig:   v1.GenericConfig{"size": 30},
+					ProjectSelector: []string{"payment-*"},
+				},
+				"payment-b": {
+					GenericConfig:   v1.GenericConfig{"size": 40},
+					ProjectSelector: []string{"payment-*"},
+				},
+				"orders": {
+					GenericConfig:   v1.GenericConfig{"size": 50},
+					ProjectSelector: []string{"order-api"},
+				},
+			},
+		},
+	}
+
+	e, err := ExplainProjectModuleConfig(config, Project{
+		Name:   "payment-api",
+		Stack:  "prod",
+		Labels: map[string]string{"team": "payments"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"instanceType": "db.t3.large", "size": 30, "region": "us-east-1"}, e.Config)
+	assert.Equal(t, []ConfigSource{
+		{Block: "payments", Selector: "labels:team=payments"},
+		{Block: "payment-b", Selector: "payment-*"},
+		{Block: "payment-a", Selector: "payment-*"},
+		{Block: "prod", Selector: "*/prod"},
+	}, e.Blocks)
+	assert.Equal(t, map[string]ConfigSource{
+		"instanceType": {Block: "prod", Selector: "*/prod"},
+		"size":         {Block: "payment-a", Selector: "payment-*"},
+		"region":       {Block: v1.DefaultBlock},
+	}, e.Sources)
+	// the default config is not modified
+	assert.Equal(t, "db.t3.micro", config.Configs.Default["instanceType"])
+
+	cfg, err := ResolveProjectModuleConfig(config, Project{Name: "order-api", Stack: "dev"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"instanceType": "db.t3.micro", "size": 50, "region": "us-east-1"}, cfg)
+}
@@ -9,6 +9,8 @@ import (
 
 // GetProjectModuleConfigs returns the module configs of a specified project, whose key is the module name, should be called after ValidateModuleConfigs.
 // If got empty module configs, return nil config and nil error.
+// The module configs are got by GetProjectModuleConfig, use ResolveProjectModuleConfigs to match the
+// stack and label selectors and apply all matched patcher blocks.
 func GetProjectModuleConfigs(configs v1.ModuleConfigs, projectName string) (map[string]v1.GenericConfig, error) {
 	if len(configs) == 0 {
 		return nil, nil
@@ -19,10 +21,38 @@ func GetProjectModuleConfigs(configs v1.ModuleConfigs, projectName string) (map[
 
 	projectConfigs := make(map[string]v1.GenericConfig)
 	for name, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
 		moduleConfig, err := getProjectModuleConfig(cfg, projectName)
-		if moduleConfig == nil {
+		if err != nil {
+			return nil, fmt.Errorf("%w, module name: %s", err, name)
+		}
+		if len(moduleConfig) != 0 {
+			projectConfigs[name] = moduleConfig
+		}
+	}
+
+	return projectConfigs, nil
+}
+
+// ResolveProjectModuleConfigs returns the module configs of the project by ResolveProjectModuleConfig,
+// whose key is the module name. The modules with empty configs are omitted, and nil is returned if
+// there are no module configs.
+func ResolveProjectModuleConfigs(configs v1.ModuleConfigs, project Project, opts *MergeOptions) (map[string]v1.GenericConfig, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	if project.Name == "" {
+		return nil, errors.New("empty project name")
+	}
+
+	projectConfigs := make(map[string]v1.GenericConfig)
+	for name, cfg := range configs {
+		if cfg == nil {
 			continue
 		}
+		moduleConfig, err := ResolveProjectModuleConfig(cfg, project, opts)
 		if err != nil {
 			return nil, fmt.Errorf("%w, module name: %s", err, name)
 		}
@@ -36,6 +66,9 @@ func GetProjectModuleConfigs(configs v1.ModuleConfigs, projectName string) (map[
 
 // GetProjectModuleConfig returns the module config of a specified project, should be called after ValidateModuleConfig.
 // If got empty module config, return nil config and nil error.
+// Only the most specific patcher block matching the project is deep merged into the default config,
+// the blocks with the same specificity are sorted by name and the first one wins. Use
+// ResolveProjectModuleConfig to match the stack and label selectors and apply all matched blocks.
 func GetProjectModuleConfig(config *v1.ModuleConfig, projectName string) (v1.GenericConfig, error) {
 	if config == nil {
 		return nil, nil
@@ -49,34 +82,19 @@ func GetProjectModuleConfig(config *v1.ModuleConfig, projectName string) (v1.Gen
 
 // getProjectModuleConfig gets the module config of a specified project without checking the correctness of project name.
 func getProjectModuleConfig(config *v1.ModuleConfig, projectName string) (v1.GenericConfig, error) {
-	projectCfg := config.Configs.Default
-	if len(projectCfg) == 0 {
-		projectCfg = make(v1.GenericConfig)
+	e, err := ExplainProjectModuleConfig(config, Project{Name: projectName}, nil)
+	if err != nil {
+		return nil, err
 	}
-
-	for name, cfg := range config.Configs.ModulePatcherConfigs {
-		if name == v1.DefaultBlock {
-			continue
-		}
-		// check the project is assigned in the block or not.
-		var contain bool
-		for _, project := range cfg.ProjectSelector {
-			if projectName == project {
-				contain = true
-				break
-			}
-		}
-		if contain {
-			for k, v := range cfg.GenericConfig {
-				if k == v1.ProjectSelectorField {
-					continue
-				}
-				projectCfg[k] = v
-			}
-			break
-		}
+	projectCfg := MergeGenericConfigs(config.Configs.Default, nil, nil)
+	if len(e.Blocks) != 0 {
+		// the blocks are in the order they are applied, so the last one is the most specific
+		block := config.Configs.ModulePatcherConfigs[e.Blocks[len(e.Blocks)-1].Block]
+		projectCfg = MergeGenericConfigs(projectCfg, patcherConfig(block), nil)
+	}
+	if len(projectCfg) == 0 {
+		return nil, nil
 	}
-
 	return projectCfg, nil
 }
 
@@ -79,6 +79,24 @@ func Test_GetProjectModuleConfigs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "failed to get configs with invalid project selector",
+			projectName: "foo",
+			moduleConfigs: v1.ModuleConfigs{
+				"mysql": {
+					Configs: v1.Configs{
+						Default: v1.GenericConfig{"type": "aws"},
+						ModulePatcherConfigs: v1.ModulePatcherConfigs{
+							"invalid": {
+								GenericConfig:   v1.GenericConfig{"type": "alicloud"},
+								ProjectSelector: []string{"foo["},
+							},
+						},
+					},
+				},
+			},
+			success: false,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -283,3 +301,84 @@ func Test_GetStringMapFieldFromGenericConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveProjectModuleConfigs(t *testing.T) {
+	configs := v1.ModuleConfigs{
+		"mysql": {
+			Configs: v1.Configs{
+				Default: v1.GenericConfig{"instanceType": "db.t3.micro"},
+				ModulePatcherConfigs: v1.ModulePatcherConfigs{
+					"prod": {
+						GenericConfig:   v1.GenericConfig{"instanceType": "db.r5.large"},
+						ProjectSelector: []string{"foo/prod"},
+					},
+					"payments": {
+						GenericConfig:   v1.GenericConfig{"multiAZ": true},
+						ProjectSelector: []string{"labels:team=payments"},
+					},
+				},
+			},
+		},
+		"network": nil,
+	}
+
+	cfgs, err := ResolveProjectModuleConfigs(configs, Project{Name: "foo", Stack: "prod", Labels: map[string]string{"team": "payments"}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]v1.GenericConfig{
+		"mysql": {"instanceType": "db.r5.large", "multiAZ": true},
+	}, cfgs)
+
+	cfgs, err = GetProjectModuleConfigs(configs, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]v1.GenericConfig{"mysql": {"instanceType": "db.t3.micro"}}, cfgs)
+
+	_, err = ResolveProjectModuleConfigs(configs, Project{}, nil)
+	assert.Error(t, err)
+	cfgs, err = ResolveProjectModuleConfigs(nil, Project{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cfgs)
+}
+
+func TestGetProjectModuleConfigMostSpecificBlockWins(t *testing.T) {
+	config := &v1.ModuleConfig{
+		Configs: v1.Configs{
+			Default: v1.GenericConfig{"instanceType": "db.t3.micro", "labels": map[string]any{"app": "db"}},
+			ModulePatcherConfigs: v1.ModulePatcherConfigs{
+				"all": {
+					GenericConfig:   v1.GenericConfig{"multiAZ": true},
+					ProjectSelector: []string{"*"},
+				},
+				"large": {
+					GenericConfig:   v1.GenericConfig{"instanceType": "db.t3.large"},
+					ProjectSelector: []string{"foo"},
+				},
+				"small": {
+					GenericConfig:   v1.GenericConfig{"instanceType": "db.t3.small", "labels": map[string]any{"team": "a"}},
+					ProjectSelector: []string{"foo"},
+				},
+			},
+		},
+	}
+
+	// like the baseline, only one block is applied, which is the most specific one and the first
+	// by name among the same specificity, and it is deep merged into the default config
+	cfg, err := GetProjectModuleConfig(config, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"instanceType": "db.t3.large", "labels": map[string]any{"app": "db"}}, cfg)
+	cfg, err = GetProjectModuleConfig(config, "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"instanceType": "db.t3.micro", "labels": map[string]any{"app": "db"}, "multiAZ": true}, cfg)
+
+	// while all matched blocks are layered by ResolveProjectModuleConfig
+	cfg, err = ResolveProjectModuleConfig(config, Project{Name: "foo"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.GenericConfig{"instanceType": "db.t3.large", "labels": map[string]any{"app": "db", "team": "a"}, "multiAZ": true}, cfg)
+
+	// the empty config is nil
+	cfg, err = GetProjectModuleConfig(&v1.ModuleConfig{}, "foo")
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+	cfg, err = ResolveProjectModuleConfig(&v1.ModuleConfig{}, Project{Name: "foo"}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+}